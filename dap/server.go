@@ -0,0 +1,497 @@
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/unflag/go-lox/lox"
+)
+
+// threadID is the only thread a script runs on.
+const threadID = 1
+
+type Server struct {
+	r *bufio.Reader
+	w io.Writer
+
+	// mu guards the writes of messages, which come from the script too, and
+	// stopped.
+	mu      sync.Mutex
+	seq     int
+	stopped bool
+
+	vm          *lox.VM
+	debugger    *lox.Debugger
+	stopOnEntry bool
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	// calls runs requests on the goroutine of the script while it is
+	// stopped.
+	calls chan call
+	// refs holds what each variablesReference points at, a scope or a value
+	// with children. References are only valid until the script resumes.
+	refs []any
+}
+
+type call struct {
+	fn   func()
+	done chan struct{}
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		r:     bufio.NewReader(r),
+		w:     w,
+		calls: make(chan call),
+	}
+}
+
+func (s *Server) Run() error {
+	defer s.stop()
+
+	for {
+		req, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+func (s *Server) read() (*request, error) {
+	header, err := textproto.NewReader(s.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return nil, fmt.Errorf("could not read message body: %w", err)
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	return &req, nil
+}
+
+func (s *Server) write(msg func(seq int) any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	body, err := json.Marshal(msg(s.seq))
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) reply(req *request, body any, respErr error) error {
+	return s.write(func(seq int) any {
+		resp := response{Seq: seq, Type: "response", RequestSeq: req.Seq, Success: respErr == nil, Command: req.Command, Body: body}
+		if respErr != nil {
+			resp.Message = respErr.Error()
+		}
+		return resp
+	})
+}
+
+func (s *Server) event(name string, body any) error {
+	return s.write(func(seq int) any {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+func (s *Server) handle(req *request) error {
+	var (
+		body any
+		err  error
+	)
+
+	switch req.Command {
+	case "initialize":
+		body = Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		}
+	case "launch":
+		var args launchArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			err = s.launch(args)
+		}
+		if err == nil {
+			// Breakpoints can only be checked against a loaded script, so the
+			// client configures them after the launch.
+			if err := s.reply(req, nil, nil); err != nil {
+				return err
+			}
+			return s.event("initialized", nil)
+		}
+	case "configurationDone":
+		err = s.start()
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			body = s.setBreakpoints(args)
+		}
+	case "threads":
+		body = threadsResponse{Threads: []Thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		var args stackTraceArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			err = s.inspect(func() error {
+				body = s.stackTrace(args)
+				return nil
+			})
+		}
+	case "scopes":
+		var args scopesArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			err = s.inspect(func() error {
+				body = s.scopes(args)
+				return nil
+			})
+		}
+	case "variables":
+		var args variablesArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			err = s.inspect(func() (err error) {
+				body, err = s.variables(args)
+				return err
+			})
+		}
+	case "evaluate":
+		var args evaluateArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			err = s.inspect(func() (err error) {
+				body, err = s.evaluate(args)
+				return err
+			})
+		}
+	case "continue":
+		return s.resume(req, continueResponse{AllThreadsContinued: true}, lox.StepContinue)
+	case "next":
+		return s.resume(req, nil, lox.StepOver)
+	case "stepIn":
+		return s.resume(req, nil, lox.StepIn)
+	case "stepOut":
+		return s.resume(req, nil, lox.StepOut)
+	case "terminate", "disconnect":
+		s.stop()
+	default:
+		err = fmt.Errorf("unsupported request %q", req.Command)
+	}
+
+	return s.reply(req, body, err)
+}
+
+func (s *Server) launch(args launchArguments) error {
+	if s.debugger != nil {
+		return errors.New("a program is already launched")
+	}
+
+	s.vm = lox.New(
+		lox.WithStdout(&output{s: s, category: "stdout"}),
+		lox.WithStderr(&output{s: s, category: "stderr"}),
+		// The client talks to the server over stdin, so the script reads no
+		// input.
+		lox.WithStdin(strings.NewReader("")),
+		lox.WithSearchPath(args.SearchPath...),
+	)
+
+	d, err := lox.NewDebugger(s.vm, args.Program)
+	if err != nil {
+		return errors.New(lox.DiagnosticMessage(err))
+	}
+
+	d.Stopped = s.paused
+	s.debugger = d
+	s.stopOnEntry = args.StopOnEntry
+
+	return nil
+}
+
+// start runs the launched script on its own goroutine.
+func (s *Server) start() error {
+	if s.debugger == nil {
+		return errors.New("no program is launched")
+	}
+	if s.done != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		err := s.debugger.Run(ctx, s.stopOnEntry)
+		_ = s.event("exited", exitedEvent{ExitCode: s.exitCode(err)})
+		_ = s.event("terminated", nil)
+	}()
+
+	return nil
+}
+
+func (s *Server) exitCode(err error) int {
+	var exitErr lox.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &lox.CancelError{}):
+		return 1
+	default:
+		s.vm.ReportError(err)
+		return 70
+	}
+}
+
+// stop ends the script if it is running and waits for it.
+func (s *Server) stop() {
+	if s.done == nil {
+		return
+	}
+
+	// The script either stops at a statement before it sees the context is
+	// cancelled, or ends without stopping again.
+	s.cancel()
+	quit := call{done: make(chan struct{}), fn: func() {
+		s.debugger.Quit()
+		s.setStopped(false)
+	}}
+	select {
+	case <-s.done:
+	case s.calls <- quit:
+		<-s.done
+	}
+}
+
+// paused is called by the debugger on the goroutine of the script when it
+// stops, and runs calls until one of them resumes the script.
+func (s *Server) paused(reason lox.StopReason) {
+	s.setStopped(true)
+	_ = s.event("stopped", stoppedEvent{Reason: string(reason), ThreadID: threadID, AllThreadsStopped: true})
+
+	for c := range s.calls {
+		c.fn()
+		close(c.done)
+
+		if !s.isStopped() {
+			s.refs = nil
+			return
+		}
+	}
+}
+
+func (s *Server) setStopped(stopped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = stopped
+}
+
+func (s *Server) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+// run runs fn on the goroutine of the stopped script and waits for it.
+func (s *Server) run(fn func()) {
+	c := call{fn: fn, done: make(chan struct{})}
+	s.calls <- c
+	<-c.done
+}
+
+// inspect runs fn while the script is stopped.
+func (s *Server) inspect(fn func() error) error {
+	if !s.isStopped() {
+		return errors.New("the program is not stopped")
+	}
+
+	var err error
+	s.run(func() {
+		err = fn()
+	})
+	return err
+}
+
+// resume replies to req and then resumes the stopped script in mode, so
+// the reply comes before the next stop.
+func (s *Server) resume(req *request, body any, mode lox.StepMode) error {
+	if !s.isStopped() {
+		return s.reply(req, nil, errors.New("the program is not stopped"))
+	}
+
+	if err := s.reply(req, body, nil); err != nil {
+		return err
+	}
+
+	s.run(func() {
+		s.debugger.Resume(mode)
+		s.setStopped(false)
+	})
+	return nil
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) setBreakpointsResponse {
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+	lines := make([]int, len(args.Breakpoints))
+	for n, b := range args.Breakpoints {
+		breakpoints[n] = Breakpoint{Line: b.Line}
+		lines[n] = b.Line
+	}
+
+	if s.debugger == nil || !samePath(args.Source.Path, s.debugger.File()) {
+		for n := range breakpoints {
+			breakpoints[n].Message = "Breakpoints can only be set in the launched program."
+		}
+		return setBreakpointsResponse{Breakpoints: breakpoints}
+	}
+
+	for n, verified := range s.debugger.SetBreakpoints(lines...) {
+		breakpoints[n].Verified = verified
+		if !verified {
+			breakpoints[n].Message = "No statement on this line."
+		}
+	}
+
+	return setBreakpointsResponse{Breakpoints: breakpoints}
+}
+
+func (s *Server) stackTrace(args stackTraceArguments) stackTraceResponse {
+	d := s.debugger
+	source := &Source{Name: filepath.Base(d.File()), Path: d.File()}
+
+	frames := d.Frames()
+	resp := stackTraceResponse{StackFrames: []StackFrame{}, TotalFrames: len(frames)}
+	for n := args.StartFrame; n < len(frames); n++ {
+		if args.Levels > 0 && n-args.StartFrame >= args.Levels {
+			break
+		}
+
+		frame := StackFrame{ID: n, Name: frames[n].Function, Line: frames[n].Line, Column: 1, Source: source}
+		if frames[n].Native {
+			frame.Source, frame.Line, frame.Column = nil, 0, 0
+			frame.PresentationHint = "subtle"
+		}
+		resp.StackFrames = append(resp.StackFrames, frame)
+	}
+
+	return resp
+}
+
+func (s *Server) scopes(args scopesArguments) scopesResponse {
+	resp := scopesResponse{Scopes: []Scope{}}
+	for _, scope := range s.debugger.Scopes(args.FrameID) {
+		resp.Scopes = append(resp.Scopes, Scope{
+			Name:               scope.Name,
+			VariablesReference: s.reference(scope.Variables),
+			Expensive:          scope.Name == "Globals",
+		})
+	}
+
+	return resp
+}
+
+func (s *Server) variables(args variablesArguments) (variablesResponse, error) {
+	n := args.VariablesReference - 1
+	if n < 0 || n >= len(s.refs) {
+		return variablesResponse{}, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+
+	vars, ok := s.refs[n].([]lox.DebugVariable)
+	if !ok {
+		vars = s.debugger.Children(s.refs[n])
+	}
+
+	resp := variablesResponse{Variables: []Variable{}}
+	for _, v := range vars {
+		resp.Variables = append(resp.Variables, Variable{
+			Name:               v.Name,
+			Value:              lox.FormatValue(v.Value),
+			VariablesReference: s.valueReference(v.Value),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *Server) evaluate(args evaluateArguments) (evaluateResponse, error) {
+	value, err := s.debugger.Evaluate(args.FrameID, args.Expression)
+	if err != nil {
+		return evaluateResponse{}, errors.New(lox.DiagnosticMessage(err))
+	}
+
+	return evaluateResponse{Result: lox.FormatValue(value), VariablesReference: s.valueReference(value)}, nil
+}
+
+func (s *Server) reference(target any) int {
+	s.refs = append(s.refs, target)
+	return len(s.refs)
+}
+
+// valueReference returns a reference to the children of value, or 0 if it
+// has none.
+func (s *Server) valueReference(value lox.Value) int {
+	if len(s.debugger.Children(value)) == 0 {
+		return 0
+	}
+	return s.reference(value)
+}
+
+// output sends what the script writes to the client as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	if err := o.s.event("output", outputEvent{Category: o.category, Output: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
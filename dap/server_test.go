@@ -0,0 +1,360 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	msgs   chan map[string]any
+	seq    int
+	events []map[string]any
+	err    chan error
+}
+
+func newClient(t *testing.T) *client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	c := &client{t: t, w: clientW, msgs: make(chan map[string]any, 64), err: make(chan error, 1)}
+	go func() {
+		c.err <- NewServer(serverR, serverW).Run()
+		serverW.Close()
+	}()
+
+	// The server sends events while the script runs, so the client reads
+	// all the time, like a real one.
+	go func() {
+		defer close(c.msgs)
+
+		r := bufio.NewReader(clientR)
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			length, err := strconv.Atoi(header.Get("Content-Length"))
+			if err != nil {
+				return
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+
+			var msg map[string]any
+			if err := json.Unmarshal(body, &msg); err != nil {
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+
+	return c
+}
+
+func (c *client) receive() map[string]any {
+	msg, ok := <-c.msgs
+	require.True(c.t, ok, "the server closed the connection")
+	return msg
+}
+
+// request sends a request and returns its response, keeping the events
+// that come before it.
+func (c *client) request(command string, args any) map[string]any {
+	c.seq++
+	body, err := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(c.t, err)
+
+	for {
+		msg := c.receive()
+		if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+
+		require.Equal(c.t, "response", msg["type"])
+		require.EqualValues(c.t, c.seq, msg["request_seq"])
+		require.Equal(c.t, command, msg["command"])
+		return msg
+	}
+}
+
+// body returns the body of a successful response to the request.
+func (c *client) body(command string, args any, out any) {
+	resp := c.request(command, args)
+	require.Equal(c.t, true, resp["success"], resp["message"])
+	c.roundTrip(resp["body"], out)
+}
+
+// event waits for the next event called name and returns its body.
+func (c *client) event(name string) map[string]any {
+	for {
+		var msg map[string]any
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.receive()
+		}
+
+		if msg["event"] == name {
+			body, _ := msg["body"].(map[string]any)
+			return body
+		}
+	}
+}
+
+// output collects the output events up to the end of the script.
+func (c *client) output() (stdout, stderr string, exitCode float64) {
+	for {
+		var msg map[string]any
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.receive()
+		}
+
+		body, _ := msg["body"].(map[string]any)
+		switch msg["event"] {
+		case "output":
+			if body["category"] == "stderr" {
+				stderr += body["output"].(string)
+			} else {
+				stdout += body["output"].(string)
+			}
+		case "exited":
+			exitCode = body["exitCode"].(float64)
+		case "terminated":
+			return stdout, stderr, exitCode
+		}
+	}
+}
+
+func (c *client) roundTrip(v any, out any) {
+	body, err := json.Marshal(v)
+	require.NoError(c.t, err)
+	require.NoError(c.t, json.Unmarshal(body, out))
+}
+
+func (c *client) stack() []StackFrame {
+	var resp stackTraceResponse
+	c.body("stackTrace", map[string]any{"threadId": threadID}, &resp)
+	return resp.StackFrames
+}
+
+func (c *client) variables(ref int) map[string]Variable {
+	var resp variablesResponse
+	c.body("variables", map[string]any{"variablesReference": ref}, &resp)
+
+	vars := make(map[string]Variable)
+	for _, v := range resp.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func (c *client) disconnect() {
+	resp := c.request("disconnect", map[string]any{})
+	require.Equal(c.t, true, resp["success"])
+	require.NoError(c.t, <-c.err)
+}
+
+func writeScript(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "main.lox")
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	return path
+}
+
+const testScript = `fun square(x) {
+  var result = x * x;
+  return result;
+}
+var items = [1, "two"];
+var total = 0;
+for (var i = 1; i <= 2; i = i + 1) {
+  total = total + square(i);
+}
+print total;
+`
+
+func Test_Server(t *testing.T) {
+	path := writeScript(t, testScript)
+	c := newClient(t)
+
+	var caps Capabilities
+	c.body("initialize", map[string]any{"adapterID": "lox"}, &caps)
+	require.True(t, caps.SupportsConfigurationDoneRequest)
+
+	c.body("launch", map[string]any{"program": path}, &struct{}{})
+	c.event("initialized")
+
+	var breakpoints setBreakpointsResponse
+	c.body("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}, {"line": 4}},
+	}, &breakpoints)
+	require.Len(t, breakpoints.Breakpoints, 2)
+	require.True(t, breakpoints.Breakpoints[0].Verified)
+	require.False(t, breakpoints.Breakpoints[1].Verified)
+
+	c.body("configurationDone", nil, &struct{}{})
+	require.Equal(t, "breakpoint", c.event("stopped")["reason"])
+
+	t.Run("threads", func(t *testing.T) {
+		var resp threadsResponse
+		c.body("threads", nil, &resp)
+		require.Equal(t, []Thread{{ID: threadID, Name: "main"}}, resp.Threads)
+	})
+
+	t.Run("stackTrace", func(t *testing.T) {
+		frames := c.stack()
+		require.Len(t, frames, 2)
+		require.Equal(t, "square", frames[0].Name)
+		require.Equal(t, 3, frames[0].Line)
+		require.Equal(t, path, frames[0].Source.Path)
+		require.Equal(t, "<script>", frames[1].Name)
+		require.Equal(t, 8, frames[1].Line)
+	})
+
+	t.Run("scopes and variables", func(t *testing.T) {
+		var resp scopesResponse
+		c.body("scopes", map[string]any{"frameId": 0}, &resp)
+		require.Len(t, resp.Scopes, 2)
+		require.Equal(t, "Locals", resp.Scopes[0].Name)
+		require.Equal(t, "Globals", resp.Scopes[1].Name)
+
+		locals := c.variables(resp.Scopes[0].VariablesReference)
+		require.Len(t, locals, 2)
+		require.Equal(t, "1", locals["x"].Value)
+		require.Equal(t, "1", locals["result"].Value)
+
+		globals := c.variables(resp.Scopes[1].VariablesReference)
+		require.Equal(t, "<fn square>", globals["square"].Value)
+		require.Equal(t, "0", globals["total"].Value)
+		require.NotContains(t, globals, "clock")
+
+		items := globals["items"]
+		require.Equal(t, `[1, "two"]`, items.Value)
+		elems := c.variables(items.VariablesReference)
+		require.Equal(t, `"two"`, elems["[1]"].Value)
+
+		resp = scopesResponse{}
+		c.body("scopes", map[string]any{"frameId": 1}, &resp)
+		require.Len(t, resp.Scopes, 3)
+		require.Equal(t, "Locals", resp.Scopes[0].Name)
+		require.Empty(t, c.variables(resp.Scopes[0].VariablesReference))
+		require.Equal(t, "Enclosing", resp.Scopes[1].Name)
+		require.Equal(t, "1", c.variables(resp.Scopes[1].VariablesReference)["i"].Value)
+		require.Equal(t, "Globals", resp.Scopes[2].Name)
+
+		unknown := c.request("variables", map[string]any{"variablesReference": 100})
+		require.Equal(t, false, unknown["success"])
+	})
+
+	t.Run("evaluate", func(t *testing.T) {
+		var resp evaluateResponse
+		c.body("evaluate", map[string]any{"expression": "result * 10", "frameId": 0}, &resp)
+		require.Equal(t, "10", resp.Result)
+
+		c.body("evaluate", map[string]any{"expression": "x = 3", "frameId": 0}, &resp)
+		require.Equal(t, "3", resp.Result)
+		c.body("evaluate", map[string]any{"expression": "x", "frameId": 0}, &resp)
+		require.Equal(t, "3", resp.Result)
+
+		c.body("evaluate", map[string]any{"expression": "items", "frameId": 1}, &resp)
+		require.NotZero(t, resp.VariablesReference)
+
+		failed := c.request("evaluate", map[string]any{"expression": "missing", "frameId": 0})
+		require.Equal(t, false, failed["success"])
+		require.Equal(t, "Undefined variable", failed["message"])
+	})
+
+	t.Run("stepping", func(t *testing.T) {
+		c.body("stepOut", map[string]any{"threadId": threadID}, &struct{}{})
+		require.Equal(t, "step", c.event("stopped")["reason"])
+		frames := c.stack()
+		require.Len(t, frames, 1)
+		require.Equal(t, 8, frames[0].Line)
+
+		c.body("stepIn", map[string]any{"threadId": threadID}, &struct{}{})
+		require.Equal(t, "step", c.event("stopped")["reason"])
+		frames = c.stack()
+		require.Len(t, frames, 2)
+		require.Equal(t, 2, frames[0].Line)
+
+		c.body("setBreakpoints", map[string]any{"source": map[string]any{"path": path}, "breakpoints": []any{}}, &breakpoints)
+		c.body("next", map[string]any{"threadId": threadID}, &struct{}{})
+		require.Equal(t, "step", c.event("stopped")["reason"])
+		require.Equal(t, 3, c.stack()[0].Line)
+	})
+
+	var resp continueResponse
+	c.body("continue", map[string]any{"threadId": threadID}, &resp)
+	require.True(t, resp.AllThreadsContinued)
+
+	stdout, stderr, code := c.output()
+	require.Equal(t, "5\n", stdout)
+	require.Empty(t, stderr)
+	require.Zero(t, code)
+
+	c.disconnect()
+}
+
+func Test_ServerErrors(t *testing.T) {
+	t.Run("missing program", func(t *testing.T) {
+		c := newClient(t)
+		resp := c.request("launch", map[string]any{"program": filepath.Join(t.TempDir(), "missing.lox")})
+		require.Equal(t, false, resp["success"])
+		require.Contains(t, resp["message"], "read file failed")
+		c.disconnect()
+	})
+
+	t.Run("not stopped", func(t *testing.T) {
+		c := newClient(t)
+		c.body("launch", map[string]any{"program": writeScript(t, "print 1;")}, &struct{}{})
+		resp := c.request("stackTrace", map[string]any{"threadId": threadID})
+		require.Equal(t, false, resp["success"])
+		require.Equal(t, "the program is not stopped", resp["message"])
+		c.disconnect()
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		c := newClient(t)
+		c.body("launch", map[string]any{"program": writeScript(t, "print 1;\n1 / 0;\n")}, &struct{}{})
+		c.body("configurationDone", nil, &struct{}{})
+
+		stdout, stderr, code := c.output()
+		require.Equal(t, "1\n", stdout)
+		require.Contains(t, stderr, "Division by zero")
+		require.EqualValues(t, 70, code)
+		c.disconnect()
+	})
+
+	t.Run("disconnect while stopped", func(t *testing.T) {
+		c := newClient(t)
+		c.body("launch", map[string]any{"program": writeScript(t, "print 1;\nprint 2;\n"), "stopOnEntry": true}, &struct{}{})
+		c.body("configurationDone", nil, &struct{}{})
+		require.Equal(t, "entry", c.event("stopped")["reason"])
+		c.disconnect()
+	})
+
+	t.Run("disconnect while running", func(t *testing.T) {
+		c := newClient(t)
+		c.body("launch", map[string]any{"program": writeScript(t, "while (true) {}\n")}, &struct{}{})
+		c.body("configurationDone", nil, &struct{}{})
+		c.disconnect()
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/unflag/go-lox/lox"
+)
+
+func runDebug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	searchPath := flags.String("path", "", "list of directories to search for imported modules")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s debug [-path dirs] script\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	vm := lox.New(lox.WithSearchPath(filepath.SplitList(*searchPath)...))
+	if err := vm.Debug(context.Background(), flags.Arg(0), os.Stdin, os.Stdout); err != nil {
+		exitOnExitError(err)
+		code, ok := exitCode(err)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "could not debug file %s: %+v\n", flags.Arg(0), err)
+			return 1
+		}
+		vm.ReportError(err)
+		return code
+	}
+
+	return 0
+}
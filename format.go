@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/unflag/go-lox/lox"
+)
+
+func runFormat(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s fmt [-w] [-d] [path ...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "cannot use -w with standard input")
+			return 2
+		}
+
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read input: %+v\n", err)
+			return 1
+		}
+		return formatFile("<stdin>", src, false, *diff)
+	}
+
+	code := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read file %s: %+v\n", path, err)
+			code = 1
+			continue
+		}
+
+		if c := formatFile(path, src, *write, *diff); c != 0 {
+			code = c
+		}
+	}
+
+	return code
+}
+
+func formatFile(path string, src []byte, write, diff bool) int {
+	formatted, err := lox.Format(path, string(src))
+	if err != nil {
+		lox.WriteErrors(os.Stderr, lox.ErrorFormatHuman, err)
+		return 65
+	}
+
+	changed := !bytes.Equal(src, []byte(formatted))
+
+	if diff && changed {
+		fmt.Print(unifiedDiff(path, string(src), formatted))
+	}
+
+	if write {
+		if changed {
+			if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "could not write file %s: %+v\n", path, err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	if !diff {
+		fmt.Print(formatted)
+	}
+
+	return 0
+}
+
+const diffContext = 3
+
+// unifiedDiff renders a line based diff of a and b in unified format.
+func unifiedDiff(path, a, b string) string {
+	x, y := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+		i, j int
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			edits = append(edits, edit{' ', x[i], i, j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', x[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', y[j], i, j})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+
+		from := max(start-diffContext, 0)
+		end := start
+		for k := start; k < len(edits) && k-end <= 2*diffContext; k++ {
+			if edits[k].op != ' ' {
+				end = k
+			}
+		}
+		to := min(end+diffContext+1, len(edits))
+
+		var removed, added int
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				removed++
+			}
+			if e.op != '-' {
+				added++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", edits[from].i+1, removed, edits[from].j+1, added)
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.line)
+		}
+
+		start = to
+	}
+
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 import (
 	"fmt"
@@ -47,6 +47,50 @@ func (p *Printer[T]) VisitCallExpr(e *Call) T {
 	return T(fmt.Sprintf("(funcion call with args [%v])", e.Paren.String()))
 }
 
+func (p *Printer[T]) VisitGetExpr(e *Get) T {
+	return T(fmt.Sprintf("(. %v %s)", AcceptExprVisitor[T](e.Object, p), e.Name.Lexeme))
+}
+
+func (p *Printer[T]) VisitSetExpr(e *Set) T {
+	return T(fmt.Sprintf("(= (. %v %s) %v)", AcceptExprVisitor[T](e.Object, p), e.Name.Lexeme, AcceptExprVisitor[T](e.Value, p)))
+}
+
+func (p *Printer[T]) VisitThisExpr(e *This) T {
+	return T(e.Keyword.Lexeme)
+}
+
+func (p *Printer[T]) VisitSuperExpr(e *Super) T {
+	return T(fmt.Sprintf("(. %s %s)", e.Keyword.Lexeme, e.Method.Lexeme))
+}
+
+func (p *Printer[T]) VisitLambdaExpr(e *Lambda) T {
+	return T(fmt.Sprintf("(fun %s)", e.Function.Name.Lexeme))
+}
+
+func (p *Printer[T]) VisitInterpolationExpr(e *Interpolation) T {
+	return p.parenthesize("interpolate", e.Exprs...)
+}
+
+func (p *Printer[T]) VisitListLiteralExpr(e *ListLiteral) T {
+	return p.parenthesize("list", e.Elements...)
+}
+
+func (p *Printer[T]) VisitMapLiteralExpr(e *MapLiteral) T {
+	entries := make([]Expr, 0, 2*len(e.Keys))
+	for n := range e.Keys {
+		entries = append(entries, e.Keys[n], e.Values[n])
+	}
+	return p.parenthesize("map", entries...)
+}
+
+func (p *Printer[T]) VisitIndexExpr(e *Index) T {
+	return p.parenthesize("[]", e.Object, e.Index)
+}
+
+func (p *Printer[T]) VisitIndexSetExpr(e *IndexSet) T {
+	return p.parenthesize("[]=", e.Object, e.Index, e.Value)
+}
+
 func (p *Printer[T]) parenthesize(name string, exprs ...Expr) T {
 	expression := make([]string, 0, len(exprs))
 	for _, e := range exprs {
@@ -0,0 +1,291 @@
+// Code generated by go generate; DO NOT EDIT.
+// Source: generator/generator.go
+
+package lox
+
+type Expr interface{}
+
+type ExprVisitor[T any] interface {
+	VisitAssignExpr(x *Assign) T
+	VisitBinaryExpr(x *Binary) T
+	VisitCallExpr(x *Call) T
+	VisitGetExpr(x *Get) T
+	VisitGroupingExpr(x *Grouping) T
+	VisitIndexExpr(x *Index) T
+	VisitIndexSetExpr(x *IndexSet) T
+	VisitInterpolationExpr(x *Interpolation) T
+	VisitLambdaExpr(x *Lambda) T
+	VisitListLiteralExpr(x *ListLiteral) T
+	VisitLiteralExpr(x *Literal) T
+	VisitLogicalExpr(x *Logical) T
+	VisitMapLiteralExpr(x *MapLiteral) T
+	VisitSetExpr(x *Set) T
+	VisitSuperExpr(x *Super) T
+	VisitThisExpr(x *This) T
+	VisitUnaryExpr(x *Unary) T
+	VisitVariableExpr(x *Variable) T
+}
+
+func AcceptExprVisitor[T any](a Expr, v ExprVisitor[T]) T {
+	switch a := a.(type) {
+	case *Assign:
+		return v.VisitAssignExpr(a)
+	case *Binary:
+		return v.VisitBinaryExpr(a)
+	case *Call:
+		return v.VisitCallExpr(a)
+	case *Get:
+		return v.VisitGetExpr(a)
+	case *Grouping:
+		return v.VisitGroupingExpr(a)
+	case *Index:
+		return v.VisitIndexExpr(a)
+	case *IndexSet:
+		return v.VisitIndexSetExpr(a)
+	case *Interpolation:
+		return v.VisitInterpolationExpr(a)
+	case *Lambda:
+		return v.VisitLambdaExpr(a)
+	case *ListLiteral:
+		return v.VisitListLiteralExpr(a)
+	case *Literal:
+		return v.VisitLiteralExpr(a)
+	case *Logical:
+		return v.VisitLogicalExpr(a)
+	case *MapLiteral:
+		return v.VisitMapLiteralExpr(a)
+	case *Set:
+		return v.VisitSetExpr(a)
+	case *Super:
+		return v.VisitSuperExpr(a)
+	case *This:
+		return v.VisitThisExpr(a)
+	case *Unary:
+		return v.VisitUnaryExpr(a)
+	case *Variable:
+		return v.VisitVariableExpr(a)
+	default:
+	}
+	return any(nil).(T)
+}
+
+type Assign struct {
+	Name  *Token
+	Value Expr
+}
+
+type Binary struct {
+	Left     Expr
+	Operator *Token
+	Right    Expr
+}
+
+type Call struct {
+	Args   []Expr
+	Callee Expr
+	Paren  *Token
+}
+
+type Get struct {
+	Name   *Token
+	Object Expr
+}
+
+type Grouping struct {
+	Expression Expr
+}
+
+type Index struct {
+	Bracket *Token
+	Index   Expr
+	Object  Expr
+}
+
+type IndexSet struct {
+	Bracket *Token
+	Index   Expr
+	Object  Expr
+	Value   Expr
+}
+
+type Interpolation struct {
+	Exprs   []Expr
+	Strings []*Token
+}
+
+type Lambda struct {
+	Function *Function
+	Keyword  *Token
+}
+
+type ListLiteral struct {
+	Bracket  *Token
+	Elements []Expr
+}
+
+type Literal struct {
+	Token *Token
+	Value any
+}
+
+type Logical struct {
+	Left     Expr
+	Operator *Token
+	Right    Expr
+}
+
+type MapLiteral struct {
+	Brace  *Token
+	Keys   []Expr
+	Values []Expr
+}
+
+type Set struct {
+	Name   *Token
+	Object Expr
+	Value  Expr
+}
+
+type Super struct {
+	Keyword *Token
+	Method  *Token
+}
+
+type This struct {
+	Keyword *Token
+}
+
+type Unary struct {
+	Operator *Token
+	Right    Expr
+}
+
+type Variable struct {
+	Name *Token
+}
+
+type Stmt interface{}
+
+type StmtVisitor[T any] interface {
+	VisitBlockStmt(x *Block)
+	VisitBreakStmt(x *Break)
+	VisitClassStmt(x *Class)
+	VisitContinueStmt(x *Continue)
+	VisitExpressionStmt(x *Expression)
+	VisitFunctionStmt(x *Function)
+	VisitIfStmt(x *If)
+	VisitImportStmt(x *Import)
+	VisitPrintStmt(x *Print)
+	VisitReturnStmt(x *Return)
+	VisitThrowStmt(x *Throw)
+	VisitTryStmt(x *Try)
+	VisitVarStmt(x *Var)
+	VisitWhileStmt(x *While)
+}
+
+func AcceptStmtVisitor[T any](a Stmt, v StmtVisitor[T]) {
+	switch a := a.(type) {
+	case *Block:
+		v.VisitBlockStmt(a)
+	case *Break:
+		v.VisitBreakStmt(a)
+	case *Class:
+		v.VisitClassStmt(a)
+	case *Continue:
+		v.VisitContinueStmt(a)
+	case *Expression:
+		v.VisitExpressionStmt(a)
+	case *Function:
+		v.VisitFunctionStmt(a)
+	case *If:
+		v.VisitIfStmt(a)
+	case *Import:
+		v.VisitImportStmt(a)
+	case *Print:
+		v.VisitPrintStmt(a)
+	case *Return:
+		v.VisitReturnStmt(a)
+	case *Throw:
+		v.VisitThrowStmt(a)
+	case *Try:
+		v.VisitTryStmt(a)
+	case *Var:
+		v.VisitVarStmt(a)
+	case *While:
+		v.VisitWhileStmt(a)
+	default:
+	}
+}
+
+type Block struct {
+	Statements []Stmt
+}
+
+type Break struct {
+	Keyword *Token
+}
+
+type Class struct {
+	Methods    []*Function
+	Name       *Token
+	Superclass *Variable
+}
+
+type Continue struct {
+	Keyword *Token
+}
+
+type Expression struct {
+	Expression Expr
+}
+
+type Function struct {
+	Body   []Stmt
+	Name   *Token
+	Params []*Token
+}
+
+type If struct {
+	ElseBranch Stmt
+	Expression Expr
+	ThenBranch Stmt
+}
+
+type Import struct {
+	Keyword *Token
+	Name    *Token
+	Path    *Token
+}
+
+type Print struct {
+	Expression Expr
+}
+
+type Return struct {
+	Keyword *Token
+	Value   Expr
+}
+
+type Throw struct {
+	Keyword *Token
+	Value   Expr
+}
+
+type Try struct {
+	Body      *Block
+	Catch     *Block
+	CatchName *Token
+	Finally   *Block
+	Keyword   *Token
+}
+
+type Var struct {
+	Initializer Expr
+	Name        *Token
+}
+
+type While struct {
+	Body      Stmt
+	Condition Expr
+	Increment Expr
+}
@@ -0,0 +1,250 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+)
+
+type OpCode byte
+
+const (
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	OP_GET_SUPER
+	OP_EQUAL
+	OP_NOT_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	OP_LOOP
+	OP_CALL
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	OP_CLASS
+	OP_INHERIT
+	OP_METHOD
+	OP_LIST
+	OP_MAP
+	OP_GET_INDEX
+	OP_SET_INDEX
+	OP_TRY
+	OP_END_TRY
+	OP_THROW
+	OP_IMPORT
+	OP_INTERPOLATE
+)
+
+func (op OpCode) String() string {
+	switch op {
+	case OP_CONSTANT:
+		return "OP_CONSTANT"
+	case OP_NIL:
+		return "OP_NIL"
+	case OP_TRUE:
+		return "OP_TRUE"
+	case OP_FALSE:
+		return "OP_FALSE"
+	case OP_POP:
+		return "OP_POP"
+	case OP_GET_LOCAL:
+		return "OP_GET_LOCAL"
+	case OP_SET_LOCAL:
+		return "OP_SET_LOCAL"
+	case OP_GET_GLOBAL:
+		return "OP_GET_GLOBAL"
+	case OP_DEFINE_GLOBAL:
+		return "OP_DEFINE_GLOBAL"
+	case OP_SET_GLOBAL:
+		return "OP_SET_GLOBAL"
+	case OP_GET_UPVALUE:
+		return "OP_GET_UPVALUE"
+	case OP_SET_UPVALUE:
+		return "OP_SET_UPVALUE"
+	case OP_GET_PROPERTY:
+		return "OP_GET_PROPERTY"
+	case OP_SET_PROPERTY:
+		return "OP_SET_PROPERTY"
+	case OP_GET_SUPER:
+		return "OP_GET_SUPER"
+	case OP_EQUAL:
+		return "OP_EQUAL"
+	case OP_NOT_EQUAL:
+		return "OP_NOT_EQUAL"
+	case OP_GREATER:
+		return "OP_GREATER"
+	case OP_GREATER_EQUAL:
+		return "OP_GREATER_EQUAL"
+	case OP_LESS:
+		return "OP_LESS"
+	case OP_LESS_EQUAL:
+		return "OP_LESS_EQUAL"
+	case OP_ADD:
+		return "OP_ADD"
+	case OP_SUBTRACT:
+		return "OP_SUBTRACT"
+	case OP_MULTIPLY:
+		return "OP_MULTIPLY"
+	case OP_DIVIDE:
+		return "OP_DIVIDE"
+	case OP_NOT:
+		return "OP_NOT"
+	case OP_NEGATE:
+		return "OP_NEGATE"
+	case OP_PRINT:
+		return "OP_PRINT"
+	case OP_JUMP:
+		return "OP_JUMP"
+	case OP_JUMP_IF_FALSE:
+		return "OP_JUMP_IF_FALSE"
+	case OP_LOOP:
+		return "OP_LOOP"
+	case OP_CALL:
+		return "OP_CALL"
+	case OP_CLOSURE:
+		return "OP_CLOSURE"
+	case OP_CLOSE_UPVALUE:
+		return "OP_CLOSE_UPVALUE"
+	case OP_RETURN:
+		return "OP_RETURN"
+	case OP_CLASS:
+		return "OP_CLASS"
+	case OP_INHERIT:
+		return "OP_INHERIT"
+	case OP_METHOD:
+		return "OP_METHOD"
+	case OP_LIST:
+		return "OP_LIST"
+	case OP_MAP:
+		return "OP_MAP"
+	case OP_GET_INDEX:
+		return "OP_GET_INDEX"
+	case OP_SET_INDEX:
+		return "OP_SET_INDEX"
+	case OP_TRY:
+		return "OP_TRY"
+	case OP_END_TRY:
+		return "OP_END_TRY"
+	case OP_THROW:
+		return "OP_THROW"
+	case OP_IMPORT:
+		return "OP_IMPORT"
+	case OP_INTERPOLATE:
+		return "OP_INTERPOLATE"
+	default:
+		return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+	}
+}
+
+type Chunk struct {
+	Code      []byte
+	Constants []any
+	Lines     []int
+	// Tokens holds the token every byte of Code was compiled at, which
+	// places runtime errors on its line and column.
+	Tokens []*Token
+	Source *Source
+}
+
+func newChunk() *Chunk {
+	return &Chunk{}
+}
+
+func (c *Chunk) write(b byte, line int, token *Token) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+	c.Tokens = append(c.Tokens, token)
+}
+
+func (c *Chunk) addConstant(value any) int {
+	for n, constant := range c.Constants {
+		if constant == value {
+			return n
+		}
+	}
+
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+func (c *Chunk) readUint16(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
+
+func (c *Chunk) Disassemble(w io.Writer, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+	for offset := 0; offset < len(c.Code); {
+		offset = c.disassembleInstruction(w, offset)
+	}
+}
+
+func (c *Chunk) disassembleInstruction(w io.Writer, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && c.Lines[offset] == c.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", c.Lines[offset])
+	}
+
+	op := OpCode(c.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD, OP_IMPORT:
+		constant := c.readUint16(offset + 1)
+		fmt.Fprintf(w, "%-16s %4d '%v'\n", op, constant, c.Constants[constant])
+		return offset + 3
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+		fmt.Fprintf(w, "%-16s %4d\n", op, c.Code[offset+1])
+		return offset + 2
+	case OP_LIST, OP_MAP, OP_INTERPOLATE:
+		fmt.Fprintf(w, "%-16s %4d\n", op, c.readUint16(offset+1))
+		return offset + 3
+	case OP_JUMP, OP_JUMP_IF_FALSE, OP_TRY:
+		jump := c.readUint16(offset + 1)
+		fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+jump)
+		return offset + 3
+	case OP_LOOP:
+		jump := c.readUint16(offset + 1)
+		fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3-jump)
+		return offset + 3
+	case OP_CLOSURE:
+		constant := c.readUint16(offset + 1)
+		fmt.Fprintf(w, "%-16s %4d %v\n", op, constant, c.Constants[constant])
+		offset += 3
+		fn := c.Constants[constant].(*vmFunction)
+		for n := 0; n < fn.upvalueCount; n++ {
+			kind := "upvalue"
+			if c.Code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(w, "%04d    |                     %s %d\n", offset, kind, c.Code[offset+1])
+			offset += 2
+		}
+		return offset
+	default:
+		fmt.Fprintf(w, "%s\n", op)
+		return offset + 1
+	}
+}
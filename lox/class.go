@@ -0,0 +1,82 @@
+package lox
+
+import "fmt"
+
+type loxClass[T any] struct {
+	name       string
+	superclass *loxClass[T]
+	methods    map[string]*loxFunction[T]
+}
+
+func newLoxClass[T any](name string, superclass *loxClass[T], methods map[string]*loxFunction[T]) *loxClass[T] {
+	return &loxClass[T]{
+		name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}
+}
+
+func (c *loxClass[T]) call(i *Interpreter[T], paren *Token, args []any) T {
+	instance := newLoxInstance(c)
+	if initializer := c.findMethod("init"); initializer != nil {
+		initializer.bind(instance).call(i, paren, args)
+	}
+
+	return any(instance).(T)
+}
+
+func (c *loxClass[T]) arity() int {
+	if initializer := c.findMethod("init"); initializer != nil {
+		return initializer.arity()
+	}
+
+	return 0
+}
+
+func (c *loxClass[T]) findMethod(name string) *loxFunction[T] {
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+
+	return nil
+}
+
+func (c *loxClass[T]) String() string {
+	return c.name
+}
+
+type loxInstance[T any] struct {
+	class  *loxClass[T]
+	fields map[string]any
+}
+
+func newLoxInstance[T any](class *loxClass[T]) *loxInstance[T] {
+	return &loxInstance[T]{
+		class:  class,
+		fields: make(map[string]any),
+	}
+}
+
+func (li *loxInstance[T]) get(name *Token) any {
+	if value, ok := li.fields[name.Lexeme]; ok {
+		return value
+	}
+
+	if method := li.class.findMethod(name.Lexeme); method != nil {
+		return method.bind(li)
+	}
+
+	panic(NewRuntimeError(name, fmt.Sprintf("Undefined property '%s'.", name.Lexeme)))
+}
+
+func (li *loxInstance[T]) set(name *Token, value any) {
+	li.fields[name.Lexeme] = value
+}
+
+func (li *loxInstance[T]) String() string {
+	return fmt.Sprintf("%s instance", li.class.name)
+}
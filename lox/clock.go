@@ -0,0 +1,158 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Clock is the source of time for the clock, now and sleep built-ins. The
+// date built-ins use the location of the times it returns.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock makes the built-ins read time from clock, which lets tests drive
+// scripts with a fake clock.
+func WithClock(clock Clock) Option {
+	return func(vm *VM) {
+		vm.host.setClock(clock)
+	}
+}
+
+const defaultDateLayout = "%Y-%m-%dT%H:%M:%S%z"
+
+var dateDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+	'Z': "MST",
+}
+
+// dateLayout is a strftime style layout split into directives, held as Go
+// layouts, and literal text, which Go would otherwise read as layout tokens.
+type dateLayout []dateField
+
+type dateField struct {
+	layout string
+	text   string
+}
+
+func parseDateLayout(name, layout string) (dateLayout, error) {
+	var fields dateLayout
+	literal := func(s string) {
+		if n := len(fields); n > 0 && fields[n-1].layout == "" {
+			fields[n-1].text += s
+			return
+		}
+		fields = append(fields, dateField{text: s})
+	}
+
+	for n := 0; n < len(layout); n++ {
+		if layout[n] != '%' {
+			literal(layout[n : n+1])
+			continue
+		}
+
+		n++
+		if n == len(layout) {
+			return nil, fmt.Errorf("%s: layout %q ends with '%%'.", name, layout)
+		}
+		if layout[n] == '%' {
+			literal("%")
+			continue
+		}
+
+		directive, ok := dateDirectives[layout[n]]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown directive %%%c in layout %q.", name, layout[n], layout)
+		}
+		fields = append(fields, dateField{layout: directive})
+	}
+	return fields, nil
+}
+
+func (l dateLayout) format(t time.Time) string {
+	var b strings.Builder
+	for _, field := range l {
+		if field.layout == "" {
+			b.WriteString(field.text)
+		} else {
+			b.WriteString(t.Format(field.layout))
+		}
+	}
+	return b.String()
+}
+
+// parse matches the literal text of the layout itself and hands only the
+// values of the directives to Go, one per line.
+func (l dateLayout) parse(s string, loc *time.Location) (time.Time, error) {
+	var layout, value strings.Builder
+	for _, field := range l {
+		if field.layout == "" {
+			if !strings.HasPrefix(s, field.text) {
+				return time.Time{}, fmt.Errorf("expected %q", field.text)
+			}
+			s = s[len(field.text):]
+			continue
+		}
+
+		n := fieldLength(field.layout, s)
+		layout.WriteString(field.layout + "\n")
+		value.WriteString(s[:n] + "\n")
+		s = s[n:]
+	}
+
+	if s != "" {
+		return time.Time{}, fmt.Errorf("unexpected %q", s)
+	}
+	return time.ParseInLocation(layout.String(), value.String(), loc)
+}
+
+// fieldLength returns the length of the value at the start of s for the
+// directive with the Go layout layout.
+func fieldLength(layout, s string) int {
+	n := 0
+	switch layout {
+	case "Jan", "January", "Mon", "Monday", "MST", "PM":
+		for n < len(s) && unicode.IsLetter(rune(s[n])) {
+			n++
+		}
+	case "-0700":
+		if s != "" && (s[0] == '+' || s[0] == '-') {
+			n++
+		}
+		for n < len(s) && n < len(layout) && isDigit(rune(s[n])) {
+			n++
+		}
+	default:
+		for n < len(s) && n < len(layout) && isDigit(rune(s[n])) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func Test_Time(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "clock measures sleep",
+			input: "var start = clock(); sleep(1.5); clock() - start;",
+			want:  "1.5",
+		},
+		{
+			name:  "now",
+			input: "now();",
+			want:  "1.7e+09",
+		},
+		{
+			name:  "format in clock location",
+			input: `formatDate(now());`,
+			want:  "2023-11-14T23:13:20+0100",
+		},
+		{
+			name:  "format layout",
+			input: `formatDate(now() + 0.5, "%a %d %b %Y, %I:%M %p %Z %%");`,
+			want:  "Tue 14 Nov 2023, 11:13 PM CET %",
+		},
+		{
+			name:  "parse",
+			input: `parseDate("2023-11-14 23:13:20", "%Y-%m-%d %H:%M:%S") == now();`,
+			want:  "true",
+		},
+		{
+			name:  "parse default layout",
+			input: `parseDate("1970-01-01T00:00:10+0000");`,
+			want:  "10",
+		},
+		{
+			name:  "format literal text",
+			input: `formatDate(0, "Day %d of month 1, year 2006 at 3 PM on Mon, Jan");`,
+			want:  "Day 01 of month 1, year 2006 at 3 PM on Mon, Jan",
+		},
+		{
+			name:  "parse literal text",
+			input: `parseDate("Day 14 of Nov 2023 at 11 PM, Mon 1", "Day %d of %b %Y at %I %p, Mon 1") == parseDate("2023-11-14 23", "%Y-%m-%d %H");`,
+			want:  "true",
+		},
+		{
+			name:    "parse mismatched literal text",
+			input:   `parseDate("Day 14 of 2023", "Week %d of %Y");`,
+			wantErr: `parseDate: cannot parse "Day 14 of 2023".`,
+		},
+		{
+			name:    "parse error",
+			input:   `parseDate("yesterday");`,
+			wantErr: `parseDate: cannot parse "yesterday".`,
+		},
+		{
+			name:    "unknown directive",
+			input:   `formatDate(0, "%Q");`,
+			wantErr: `formatDate: unknown directive %Q in layout "%Q".`,
+		},
+		{
+			name:    "negative sleep",
+			input:   `sleep(-1);`,
+			wantErr: "sleep: expected non-negative number, got -1.",
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				clock := &fakeClock{now: time.Unix(1700000000, 0).In(time.FixedZone("CET", 3600))}
+				got, err := New(WithBackend(backend), WithClock(clock)).Eval(context.Background(), tc.input)
+				if tc.wantErr != "" {
+					var runtimeErr RuntimeError
+					require.ErrorAs(t, err, &runtimeErr)
+					assert.Equal(t, tc.wantErr, runtimeErr.Message)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, fmt.Sprint(got))
+			})
+		}
+	}
+}
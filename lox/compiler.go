@@ -0,0 +1,817 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	maxLocals    = math.MaxUint8 + 1
+	maxUpvalues  = math.MaxUint8 + 1
+	maxConstants = math.MaxUint16 + 1
+	maxJump      = math.MaxUint16
+)
+
+type local struct {
+	name       string
+	depth      int
+	isCaptured bool
+}
+
+type upvalueRef struct {
+	index   byte
+	isLocal bool
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+type loopCompiler struct {
+	enclosing    *loopCompiler
+	scopeDepth   int
+	handlerDepth int
+	breaks       []int
+	continues    []int
+}
+
+// tryHandler is an exception handler installed by OP_TRY. Jumps and returns
+// that leave its protected code must remove it and run its finally block.
+type tryHandler struct {
+	finally *Block
+}
+
+type Compiler struct {
+	enclosing *Compiler
+	function  *vmFunction
+	typ       functionType
+	class     *classCompiler
+	loop      *loopCompiler
+	handlers  []tryHandler
+
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+
+	line  int
+	token *Token
+	errs  *[]error
+}
+
+func newCompiler() *Compiler {
+	return &Compiler{
+		function: &vmFunction{chunk: newChunk()},
+		typ:      functionTypeNone,
+		locals:   []local{{name: "", depth: 0}},
+		errs:     &[]error{},
+	}
+}
+
+func (c *Compiler) newFunctionCompiler(name *Token, typ functionType) *Compiler {
+	slotZero := ""
+	if typ != functionTypeFunction {
+		slotZero = thisToken.Lexeme
+	}
+
+	return &Compiler{
+		enclosing: c,
+		function:  &vmFunction{name: name.Lexeme, chunk: newChunk()},
+		typ:       typ,
+		class:     c.class,
+		locals:    []local{{name: slotZero, depth: 0}},
+		line:      name.Line,
+		token:     name,
+		errs:      c.errs,
+	}
+}
+
+func (c *Compiler) Compile(stmts []Stmt) (*vmFunction, []error) {
+	for n, s := range stmts {
+		if expr, ok := s.(*Expression); ok && n == len(stmts)-1 {
+			c.compileExpr(expr.Expression)
+			c.emitOp(OP_RETURN)
+			c.function.hasResult = true
+			return c.function, *c.errs
+		}
+		c.compileStmt(s)
+	}
+	c.emitReturn()
+
+	return c.function, *c.errs
+}
+
+func (c *Compiler) compileStmt(s Stmt) {
+	AcceptStmtVisitor[any](s, c)
+}
+
+func (c *Compiler) compileExpr(e Expr) {
+	AcceptExprVisitor[any](e, c)
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return c.function.chunk
+}
+
+func (c *Compiler) error(token *Token, message string) {
+	if token == nil {
+		token = c.token
+	}
+	if token == nil {
+		token = newToken(EOF, "", nil, c.line)
+	}
+	*c.errs = append(*c.errs, NewCompileError(token, message))
+}
+
+func (c *Compiler) at(token *Token) {
+	if token == nil {
+		return
+	}
+
+	c.line, c.token = token.Line, token
+	if c.chunk().Source == nil {
+		c.chunk().Source = token.Source
+	}
+}
+
+func (c *Compiler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().write(b, c.line, c.token)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, operands ...byte) {
+	c.emit(byte(op))
+	c.emit(operands...)
+}
+
+func (c *Compiler) emitUint16(op OpCode, operand int) {
+	c.emit(byte(op), byte(operand>>8), byte(operand))
+}
+
+func (c *Compiler) emitConstant(token *Token, value any) {
+	c.emitUint16(OP_CONSTANT, c.makeConstant(token, value))
+}
+
+func (c *Compiler) makeConstant(token *Token, value any) int {
+	constant := c.chunk().addConstant(value)
+	if constant >= maxConstants {
+		c.error(token, "Too many constants in one chunk.")
+		return 0
+	}
+
+	return constant
+}
+
+func (c *Compiler) identifierConstant(name *Token) int {
+	return c.makeConstant(name, name.Lexeme)
+}
+
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emitUint16(op, maxJump)
+	return len(c.chunk().Code) - 2
+}
+
+func (c *Compiler) patchJump(token *Token, offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > maxJump {
+		c.error(token, "Too much code to jump over.")
+	}
+
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(token *Token, loopStart int) {
+	offset := len(c.chunk().Code) - loopStart + 3
+	if offset > maxJump {
+		c.error(token, "Loop body too large.")
+	}
+
+	c.emitUint16(OP_LOOP, offset)
+}
+
+func (c *Compiler) emitReturn() {
+	if c.typ == functionTypeInitializer {
+		c.emitOp(OP_GET_LOCAL, 0)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	c.emitOp(OP_RETURN)
+}
+
+func (c *Compiler) beginScope() {
+	c.scopeDepth++
+}
+
+func (c *Compiler) endScope() {
+	c.scopeDepth--
+
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		if c.locals[len(c.locals)-1].isCaptured {
+			c.emitOp(OP_CLOSE_UPVALUE)
+		} else {
+			c.emitOp(OP_POP)
+		}
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// discardLocals pops the locals deeper than depth off the stack without
+// ending their scopes, for jumps that leave a loop body early.
+func (c *Compiler) discardLocals(depth int) {
+	for n := len(c.locals) - 1; n >= 0 && c.locals[n].depth > depth; n-- {
+		if c.locals[n].isCaptured {
+			c.emitOp(OP_CLOSE_UPVALUE)
+		} else {
+			c.emitOp(OP_POP)
+		}
+	}
+}
+
+func (c *Compiler) addLocal(name *Token) {
+	if len(c.locals) >= maxLocals {
+		c.error(name, "Too many local variables in function.")
+		return
+	}
+
+	c.locals = append(c.locals, local{name: name.Lexeme, depth: -1})
+}
+
+func (c *Compiler) declareVariable(name *Token) {
+	if c.scopeDepth == 0 {
+		return
+	}
+
+	c.addLocal(name)
+}
+
+func (c *Compiler) markInitialized() {
+	if c.scopeDepth == 0 {
+		return
+	}
+
+	c.locals[len(c.locals)-1].depth = c.scopeDepth
+}
+
+func (c *Compiler) defineVariable(global int) {
+	if c.scopeDepth > 0 {
+		c.markInitialized()
+		return
+	}
+
+	c.emitUint16(OP_DEFINE_GLOBAL, global)
+}
+
+func (c *Compiler) resolveLocal(name *Token) int {
+	for n := len(c.locals) - 1; n >= 0; n-- {
+		if c.locals[n].name == name.Lexeme {
+			return n
+		}
+	}
+
+	return -1
+}
+
+func (c *Compiler) resolveUpvalue(name *Token) int {
+	if c.enclosing == nil {
+		return -1
+	}
+
+	if local := c.enclosing.resolveLocal(name); local != -1 {
+		c.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(name, byte(local), true)
+	}
+
+	if upvalue := c.enclosing.resolveUpvalue(name); upvalue != -1 {
+		return c.addUpvalue(name, byte(upvalue), false)
+	}
+
+	return -1
+}
+
+func (c *Compiler) addUpvalue(name *Token, index byte, isLocal bool) int {
+	for n, upvalue := range c.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return n
+		}
+	}
+
+	if len(c.upvalues) >= maxUpvalues {
+		c.error(name, "Too many closure variables in function.")
+		return 0
+	}
+
+	c.upvalues = append(c.upvalues, upvalueRef{index: index, isLocal: isLocal})
+	c.function.upvalueCount = len(c.upvalues)
+	return len(c.upvalues) - 1
+}
+
+func (c *Compiler) namedVariable(name *Token, assign Expr) {
+	c.at(name)
+
+	var getOp, setOp OpCode
+	var arg int
+	if arg = c.resolveLocal(name); arg != -1 {
+		getOp, setOp = OP_GET_LOCAL, OP_SET_LOCAL
+	} else if arg = c.resolveUpvalue(name); arg != -1 {
+		getOp, setOp = OP_GET_UPVALUE, OP_SET_UPVALUE
+	} else {
+		arg = c.identifierConstant(name)
+		if assign != nil {
+			c.compileExpr(assign)
+			c.at(name)
+			c.emitUint16(OP_SET_GLOBAL, arg)
+		} else {
+			c.emitUint16(OP_GET_GLOBAL, arg)
+		}
+		return
+	}
+
+	if assign != nil {
+		c.compileExpr(assign)
+		c.at(name)
+		c.emitOp(setOp, byte(arg))
+	} else {
+		c.emitOp(getOp, byte(arg))
+	}
+}
+
+func (c *Compiler) compileFunction(fn *Function, typ functionType) {
+	fc := c.newFunctionCompiler(fn.Name, typ)
+	fc.beginScope()
+
+	fc.function.arity = len(fn.Params)
+	for _, param := range fn.Params {
+		fc.declareVariable(param)
+		fc.defineVariable(0)
+	}
+
+	for _, s := range fn.Body {
+		fc.compileStmt(s)
+	}
+	fc.emitReturn()
+
+	c.at(fn.Name)
+	c.emitUint16(OP_CLOSURE, c.makeConstant(fn.Name, fc.function))
+	for _, upvalue := range fc.upvalues {
+		var isLocal byte
+		if upvalue.isLocal {
+			isLocal = 1
+		}
+		c.emit(isLocal, upvalue.index)
+	}
+}
+
+func (c *Compiler) VisitBlockStmt(s *Block) {
+	c.beginScope()
+	for _, stmt := range s.Statements {
+		c.compileStmt(stmt)
+	}
+	c.endScope()
+}
+
+func (c *Compiler) VisitClassStmt(s *Class) {
+	c.at(s.Name)
+	nameConstant := c.identifierConstant(s.Name)
+	c.declareVariable(s.Name)
+
+	c.emitUint16(OP_CLASS, nameConstant)
+	c.defineVariable(nameConstant)
+
+	c.class = &classCompiler{enclosing: c.class}
+	defer func() {
+		c.class = c.class.enclosing
+	}()
+
+	if s.Superclass != nil {
+		c.namedVariable(s.Superclass.Name, nil)
+
+		c.beginScope()
+		c.addLocal(superToken)
+		c.defineVariable(0)
+
+		c.namedVariable(s.Name, nil)
+		c.at(s.Superclass.Name)
+		c.emitOp(OP_INHERIT)
+		c.at(s.Name)
+		c.class.hasSuperclass = true
+	}
+
+	c.namedVariable(s.Name, nil)
+	for _, method := range s.Methods {
+		typ := functionTypeMethod
+		if method.Name.Lexeme == "init" {
+			typ = functionTypeInitializer
+		}
+		c.compileFunction(method, typ)
+		c.emitUint16(OP_METHOD, c.identifierConstant(method.Name))
+	}
+	c.emitOp(OP_POP)
+
+	if c.class.hasSuperclass {
+		c.endScope()
+	}
+}
+
+func (c *Compiler) VisitExpressionStmt(s *Expression) {
+	c.compileExpr(s.Expression)
+	c.emitOp(OP_POP)
+}
+
+func (c *Compiler) VisitFunctionStmt(s *Function) {
+	c.at(s.Name)
+	global := c.identifierConstant(s.Name)
+	c.declareVariable(s.Name)
+	c.markInitialized()
+	c.compileFunction(s, functionTypeFunction)
+	c.defineVariable(global)
+}
+
+func (c *Compiler) VisitIfStmt(s *If) {
+	c.compileExpr(s.Expression)
+
+	thenJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.compileStmt(s.ThenBranch)
+
+	elseJump := c.emitJump(OP_JUMP)
+	c.patchJump(nil, thenJump)
+	c.emitOp(OP_POP)
+
+	if s.ElseBranch != nil {
+		c.compileStmt(s.ElseBranch)
+	}
+	c.patchJump(nil, elseJump)
+}
+
+func (c *Compiler) VisitPrintStmt(s *Print) {
+	c.compileExpr(s.Expression)
+	c.emitOp(OP_PRINT)
+}
+
+func (c *Compiler) VisitReturnStmt(s *Return) {
+	c.at(s.Keyword)
+	if _, ok := s.Value.(*NilT); ok {
+		c.leaveHandlers(0)
+		c.emitReturn()
+		return
+	}
+
+	c.compileExpr(s.Value)
+	c.leaveHandlers(0)
+	c.emitOp(OP_RETURN)
+}
+
+func (c *Compiler) VisitThrowStmt(s *Throw) {
+	c.compileExpr(s.Value)
+	c.at(s.Keyword)
+	c.emitOp(OP_THROW)
+}
+
+// VisitTryStmt compiles try/catch/finally as nested handlers: the catch
+// handler protects the body, and the finally handler protects both so that
+// it can run the finally block before rethrowing.
+func (c *Compiler) VisitTryStmt(s *Try) {
+	c.at(s.Keyword)
+
+	var finallyHandler int
+	if s.Finally != nil {
+		finallyHandler = c.emitJump(OP_TRY)
+		c.handlers = append(c.handlers, tryHandler{finally: s.Finally})
+	}
+
+	if s.Catch != nil {
+		catchHandler := c.emitJump(OP_TRY)
+		c.handlers = append(c.handlers, tryHandler{})
+		c.compileStmt(s.Body)
+		c.handlers = c.handlers[:len(c.handlers)-1]
+		c.emitOp(OP_END_TRY)
+		end := c.emitJump(OP_JUMP)
+
+		c.patchJump(s.Keyword, catchHandler)
+		c.beginScope()
+		c.addLocal(s.CatchName)
+		c.markInitialized()
+		c.compileStmt(s.Catch)
+		c.endScope()
+		c.patchJump(s.Keyword, end)
+	} else {
+		c.compileStmt(s.Body)
+	}
+
+	if s.Finally == nil {
+		return
+	}
+
+	c.handlers = c.handlers[:len(c.handlers)-1]
+	c.emitOp(OP_END_TRY)
+	c.compileStmt(s.Finally)
+	end := c.emitJump(OP_JUMP)
+
+	// The handler receives the error as a hidden local, runs the finally
+	// block and throws it again.
+	c.patchJump(s.Keyword, finallyHandler)
+	c.beginScope()
+	c.addLocal(&Token{Lexeme: ""})
+	c.markInitialized()
+	c.compileStmt(s.Finally)
+	c.scopeDepth--
+	c.locals = c.locals[:len(c.locals)-1]
+	c.emitOp(OP_THROW)
+
+	c.patchJump(s.Keyword, end)
+}
+
+// leaveHandlers removes the handlers above depth before a jump or return,
+// running their finally blocks from the innermost outwards.
+func (c *Compiler) leaveHandlers(depth int) {
+	handlers := c.handlers
+	defer func() {
+		c.handlers = handlers
+	}()
+
+	for n := len(handlers) - 1; n >= depth; n-- {
+		c.handlers = handlers[:n]
+		c.emitOp(OP_END_TRY)
+		if handlers[n].finally != nil {
+			c.compileStmt(handlers[n].finally)
+		}
+	}
+}
+
+func (c *Compiler) VisitVarStmt(s *Var) {
+	c.at(s.Name)
+	global := 0
+	if c.scopeDepth == 0 {
+		global = c.identifierConstant(s.Name)
+	}
+	c.declareVariable(s.Name)
+
+	if s.Initializer != nil {
+		c.compileExpr(s.Initializer)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+
+	c.defineVariable(global)
+}
+
+func (c *Compiler) VisitImportStmt(s *Import) {
+	c.at(s.Keyword)
+	global := 0
+	if c.scopeDepth == 0 {
+		global = c.identifierConstant(s.Name)
+	}
+	c.declareVariable(s.Name)
+
+	c.emitUint16(OP_IMPORT, c.makeConstant(s.Path, s.Path.Literal))
+	c.defineVariable(global)
+}
+
+func (c *Compiler) VisitWhileStmt(s *While) {
+	loop := &loopCompiler{enclosing: c.loop, scopeDepth: c.scopeDepth, handlerDepth: len(c.handlers)}
+	c.loop = loop
+	defer func() {
+		c.loop = loop.enclosing
+	}()
+
+	loopStart := len(c.chunk().Code)
+	c.compileExpr(s.Condition)
+
+	exitJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.compileStmt(s.Body)
+
+	for _, jump := range loop.continues {
+		c.patchJump(nil, jump)
+	}
+	if s.Increment != nil {
+		c.compileExpr(s.Increment)
+		c.emitOp(OP_POP)
+	}
+	c.emitLoop(nil, loopStart)
+
+	c.patchJump(nil, exitJump)
+	c.emitOp(OP_POP)
+
+	for _, jump := range loop.breaks {
+		c.patchJump(nil, jump)
+	}
+}
+
+func (c *Compiler) VisitBreakStmt(s *Break) {
+	c.at(s.Keyword)
+	c.leaveHandlers(c.loop.handlerDepth)
+	c.discardLocals(c.loop.scopeDepth)
+	c.loop.breaks = append(c.loop.breaks, c.emitJump(OP_JUMP))
+}
+
+func (c *Compiler) VisitContinueStmt(s *Continue) {
+	c.at(s.Keyword)
+	c.leaveHandlers(c.loop.handlerDepth)
+	c.discardLocals(c.loop.scopeDepth)
+	c.loop.continues = append(c.loop.continues, c.emitJump(OP_JUMP))
+}
+
+func (c *Compiler) VisitAssignExpr(e *Assign) any {
+	c.namedVariable(e.Name, e.Value)
+	return nil
+}
+
+func (c *Compiler) VisitBinaryExpr(e *Binary) any {
+	c.compileExpr(e.Left)
+	c.compileExpr(e.Right)
+
+	c.at(e.Operator)
+	switch e.Operator.Type {
+	case PLUS:
+		c.emitOp(OP_ADD)
+	case MINUS:
+		c.emitOp(OP_SUBTRACT)
+	case STAR:
+		c.emitOp(OP_MULTIPLY)
+	case SLASH:
+		c.emitOp(OP_DIVIDE)
+	case EQUAL_EQUAL:
+		c.emitOp(OP_EQUAL)
+	case BANG_EQUAL:
+		c.emitOp(OP_NOT_EQUAL)
+	case GREATER:
+		c.emitOp(OP_GREATER)
+	case GREATER_EQUAL:
+		c.emitOp(OP_GREATER_EQUAL)
+	case LESS:
+		c.emitOp(OP_LESS)
+	case LESS_EQUAL:
+		c.emitOp(OP_LESS_EQUAL)
+	default:
+		c.error(e.Operator, fmt.Sprintf("Unsupported binary operator %s.", e.Operator.Lexeme))
+	}
+	return nil
+}
+
+func (c *Compiler) VisitCallExpr(e *Call) any {
+	c.compileExpr(e.Callee)
+	for _, arg := range e.Args {
+		c.compileExpr(arg)
+	}
+
+	c.at(e.Paren)
+	c.emitOp(OP_CALL, byte(len(e.Args)))
+	return nil
+}
+
+func (c *Compiler) VisitGetExpr(e *Get) any {
+	c.compileExpr(e.Object)
+	c.at(e.Name)
+	c.emitUint16(OP_GET_PROPERTY, c.identifierConstant(e.Name))
+	return nil
+}
+
+func (c *Compiler) VisitLambdaExpr(e *Lambda) any {
+	c.compileFunction(e.Function, functionTypeFunction)
+	return nil
+}
+
+func (c *Compiler) VisitInterpolationExpr(e *Interpolation) any {
+	count := 0
+	for n, str := range e.Strings {
+		if str.Literal != "" {
+			c.emitConstant(str, str.Literal)
+			count++
+		}
+		if n < len(e.Exprs) {
+			c.compileExpr(e.Exprs[n])
+			count++
+		}
+	}
+
+	c.at(e.Strings[0])
+	c.emitUint16(OP_INTERPOLATE, count)
+	return nil
+}
+
+func (c *Compiler) VisitListLiteralExpr(e *ListLiteral) any {
+	for _, element := range e.Elements {
+		c.compileExpr(element)
+	}
+
+	c.at(e.Bracket)
+	c.emitUint16(OP_LIST, len(e.Elements))
+	return nil
+}
+
+func (c *Compiler) VisitMapLiteralExpr(e *MapLiteral) any {
+	for n := range e.Keys {
+		c.compileExpr(e.Keys[n])
+		c.compileExpr(e.Values[n])
+	}
+
+	c.at(e.Brace)
+	c.emitUint16(OP_MAP, len(e.Keys))
+	return nil
+}
+
+func (c *Compiler) VisitIndexExpr(e *Index) any {
+	c.compileExpr(e.Object)
+	c.compileExpr(e.Index)
+	c.at(e.Bracket)
+	c.emitOp(OP_GET_INDEX)
+	return nil
+}
+
+func (c *Compiler) VisitIndexSetExpr(e *IndexSet) any {
+	c.compileExpr(e.Object)
+	c.compileExpr(e.Index)
+	c.compileExpr(e.Value)
+	c.at(e.Bracket)
+	c.emitOp(OP_SET_INDEX)
+	return nil
+}
+
+func (c *Compiler) VisitGroupingExpr(e *Grouping) any {
+	c.compileExpr(e.Expression)
+	return nil
+}
+
+func (c *Compiler) VisitLiteralExpr(e *Literal) any {
+	switch v := e.Value.(type) {
+	case nil, NilT:
+		c.emitOp(OP_NIL)
+	case bool:
+		if v {
+			c.emitOp(OP_TRUE)
+		} else {
+			c.emitOp(OP_FALSE)
+		}
+	default:
+		c.emitConstant(nil, v)
+	}
+	return nil
+}
+
+func (c *Compiler) VisitLogicalExpr(e *Logical) any {
+	c.compileExpr(e.Left)
+
+	c.at(e.Operator)
+	if e.Operator.Type == OR {
+		elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+		endJump := c.emitJump(OP_JUMP)
+		c.patchJump(e.Operator, elseJump)
+		c.emitOp(OP_POP)
+		c.compileExpr(e.Right)
+		c.patchJump(e.Operator, endJump)
+		return nil
+	}
+
+	endJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.compileExpr(e.Right)
+	c.patchJump(e.Operator, endJump)
+	return nil
+}
+
+func (c *Compiler) VisitSetExpr(e *Set) any {
+	c.compileExpr(e.Object)
+	c.compileExpr(e.Value)
+	c.at(e.Name)
+	c.emitUint16(OP_SET_PROPERTY, c.identifierConstant(e.Name))
+	return nil
+}
+
+func (c *Compiler) VisitSuperExpr(e *Super) any {
+	if c.class == nil || !c.class.hasSuperclass {
+		c.error(e.Keyword, "Can't use 'super' in a class with no superclass.")
+		return nil
+	}
+
+	c.namedVariable(thisToken, nil)
+	c.namedVariable(superToken, nil)
+	c.at(e.Method)
+	c.emitUint16(OP_GET_SUPER, c.identifierConstant(e.Method))
+	return nil
+}
+
+func (c *Compiler) VisitThisExpr(e *This) any {
+	c.namedVariable(e.Keyword, nil)
+	return nil
+}
+
+func (c *Compiler) VisitUnaryExpr(e *Unary) any {
+	c.compileExpr(e.Right)
+
+	c.at(e.Operator)
+	switch e.Operator.Type {
+	case MINUS:
+		c.emitOp(OP_NEGATE)
+	case BANG:
+		c.emitOp(OP_NOT)
+	}
+	return nil
+}
+
+func (c *Compiler) VisitVariableExpr(e *Variable) any {
+	c.namedVariable(e.Name, nil)
+	return nil
+}
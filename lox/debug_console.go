@@ -0,0 +1,169 @@
+package lox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const debugHelp = `Commands:
+  break LINE, b LINE    stop at LINE
+  clear LINE            remove the breakpoint at LINE
+  continue, c           run to the next breakpoint
+  step, s               stop at the next statement, inside calls too
+  next, n               stop at the next statement of this function
+  out, o                stop once this function returns
+  locals                list the local variables
+  globals               list the global variables
+  print EXPR, p EXPR    evaluate EXPR in the current frame
+  backtrace, bt         list the active calls
+  quit, q               end the script
+`
+
+// Debug runs the script at path under the debugger. It stops before the
+// first statement and then reads commands from in, writing what the
+// debugger shows to out.
+func (vm *VM) Debug(ctx context.Context, path string, in io.Reader, out io.Writer) error {
+	d, err := NewDebugger(vm, path)
+	if err != nil {
+		return err
+	}
+
+	c := &debugConsole{
+		debugger: d,
+		in:       bufio.NewScanner(in),
+		out:      out,
+		lines:    make(map[int]bool),
+	}
+	d.Stopped = c.stopped
+
+	return d.Run(ctx, true)
+}
+
+type debugConsole struct {
+	debugger *Debugger
+	in       *bufio.Scanner
+	out      io.Writer
+	lines    map[int]bool
+}
+
+func (c *debugConsole) printf(format string, args ...any) {
+	fmt.Fprintf(c.out, format, args...)
+}
+
+// stopped shows where the script stopped and runs commands until one of
+// them resumes it.
+func (c *debugConsole) stopped(reason StopReason) {
+	d := c.debugger
+	c.printf("Stopped at %s:%d (%s)\n", d.File(), d.Line(), reason)
+	c.showLine(d.Line())
+
+	for {
+		c.printf("(lox) ")
+		if !c.in.Scan() {
+			c.printf("\n")
+			d.Quit()
+			return
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(c.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "":
+		case "break", "b":
+			c.setBreakpoint(arg, true)
+		case "clear":
+			c.setBreakpoint(arg, false)
+		case "continue", "c":
+			d.Resume(StepContinue)
+			return
+		case "step", "s":
+			d.Resume(StepIn)
+			return
+		case "next", "n":
+			d.Resume(StepOver)
+			return
+		case "out", "o":
+			d.Resume(StepOut)
+			return
+		case "locals":
+			c.showVariables(d.Locals(0))
+		case "globals":
+			c.showVariables(d.Globals(0))
+		case "print", "p":
+			value, err := d.Evaluate(0, arg)
+			if err != nil {
+				c.printf("error: %s\n", DiagnosticMessage(err))
+				continue
+			}
+			c.printf("%s\n", FormatValue(value))
+		case "backtrace", "bt":
+			for n, frame := range d.Frames() {
+				if frame.Native {
+					c.printf("#%d %s [native]\n", n, frame.Function)
+					continue
+				}
+				c.printf("#%d %s at %s:%d\n", n, frame.Function, d.File(), frame.Line)
+			}
+		case "help", "h":
+			c.printf("%s", debugHelp)
+		case "quit", "q":
+			d.Quit()
+			return
+		default:
+			c.printf("Unknown command %q, type help for the list of commands.\n", command)
+		}
+	}
+}
+
+func (c *debugConsole) showLine(n int) {
+	if text, ok := c.debugger.SourceLine(n); ok {
+		c.printf("%4d | %s\n", n, text)
+	}
+}
+
+func (c *debugConsole) showVariables(vars []DebugVariable) {
+	if len(vars) == 0 {
+		c.printf("No variables.\n")
+		return
+	}
+
+	for _, v := range vars {
+		c.printf("%s = %s\n", v.Name, FormatValue(v.Value))
+	}
+}
+
+func (c *debugConsole) setBreakpoint(arg string, set bool) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 {
+		c.printf("Expected a line number, got %q.\n", arg)
+		return
+	}
+
+	if set {
+		c.lines[line] = true
+	} else {
+		delete(c.lines, line)
+	}
+
+	lines := make([]int, 0, len(c.lines))
+	for l := range c.lines {
+		lines = append(lines, l)
+	}
+	verified := c.debugger.SetBreakpoints(lines...)
+
+	switch {
+	case !set:
+		c.printf("Cleared breakpoint at line %d.\n", line)
+	case verified[slices.Index(lines, line)]:
+		c.printf("Breakpoint at line %d.\n", line)
+	default:
+		delete(c.lines, line)
+		c.printf("No statement on line %d.\n", line)
+	}
+}
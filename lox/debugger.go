@@ -0,0 +1,451 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StepMode says where the debugger stops the script after it resumes.
+type StepMode int
+
+const (
+	// StepContinue runs to the next breakpoint.
+	StepContinue StepMode = iota
+	// StepIn stops at the next statement, inside calls too.
+	StepIn
+	// StepOver stops at the next statement of the current function.
+	StepOver
+	// StepOut stops once the current function returns.
+	StepOut
+)
+
+// StopReason says why the debugger stopped the script.
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"
+	StopBreakpoint StopReason = "breakpoint"
+	StopStep       StopReason = "step"
+)
+
+// DebugFrame is an active call of a stopped script.
+type DebugFrame struct {
+	Function string
+	Line     int
+	Native   bool
+}
+
+// DebugVariable is a named value shown by the debugger.
+type DebugVariable struct {
+	Name  string
+	Value Value
+}
+
+// DebugScope is one environment of the chain a frame sees, innermost first.
+type DebugScope struct {
+	Name      string
+	Variables []DebugVariable
+}
+
+// debugQuit stops a script the debugger was told to quit.
+type debugQuit struct{}
+
+func (debugQuit) Error() string {
+	return "debugger quit"
+}
+
+func (debugQuit) halt() {}
+
+// Debugger runs a script on the tree-walking interpreter and stops it at
+// breakpoints and after steps.
+type Debugger struct {
+	// Stopped is called on the goroutine running the script every time it
+	// stops. The script resumes in the mode set by Resume when Stopped
+	// returns.
+	Stopped func(reason StopReason)
+
+	vm     *VM
+	file   string
+	stmts  []Stmt
+	lines  map[Stmt]int
+	source *Source
+
+	// mu guards breakpoints, which may change while the script runs.
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	mode        StepMode
+	entry       bool
+	quit        bool
+
+	// line and depth are where the script stopped last, prevLine and
+	// prevDepth where the last statement ran.
+	line, depth         int
+	prevLine, prevDepth int
+	// envs holds the environment of every active call by depth.
+	envs []*Environment
+}
+
+// NewDebugger loads the script at path to run under the debugger.
+func NewDebugger(vm *VM, path string) (*Debugger, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+
+	tokens, stmts, diags := parse(path, string(src))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if errs := newResolver(vm.interpreter).Resolve(stmts); len(errs) > 0 {
+		return nil, newDiagnostics(errs)
+	}
+
+	d := &Debugger{
+		vm:          vm,
+		file:        path,
+		stmts:       stmts,
+		lines:       make(map[Stmt]int),
+		source:      tokens[0].Source,
+		breakpoints: make(map[int]bool),
+	}
+	l := newLayout(tokens, stmts)
+	for stmt, sp := range l.spans {
+		if _, ok := stmt.(*Block); !ok || l.loops[stmt] != nil {
+			d.lines[stmt] = sp.start.Line
+		}
+	}
+
+	return d, nil
+}
+
+// Run executes the script until it ends or the debugger quits. With
+// stopOnEntry it stops before the first statement.
+func (d *Debugger) Run(ctx context.Context, stopOnEntry bool) error {
+	i := d.vm.interpreter
+	i.debugHook = d.hook
+	defer func() {
+		i.debugHook = nil
+	}()
+
+	if abs, err := filepath.Abs(d.file); err == nil {
+		d.vm.modules.loading = append(d.vm.modules.loading, abs)
+		defer func() {
+			d.vm.modules.loading = d.vm.modules.loading[:len(d.vm.modules.loading)-1]
+		}()
+	}
+
+	d.entry = stopOnEntry
+	d.vm.host.begin(ctx)
+	_, err := i.Interpret(d.stmts)
+	if errors.As(err, &debugQuit{}) {
+		return nil
+	}
+
+	return err
+}
+
+// SetBreakpoints replaces the breakpoints with the given lines and reports
+// which of them have a statement to stop at.
+func (d *Debugger) SetBreakpoints(lines ...int) []bool {
+	valid := make(map[int]bool)
+	for _, line := range d.lines {
+		valid[line] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints = make(map[int]bool)
+	verified := make([]bool, len(lines))
+	for n, line := range lines {
+		d.breakpoints[line] = valid[line]
+		verified[n] = valid[line]
+	}
+
+	return verified
+}
+
+// Resume sets where the script stops next once Stopped returns.
+func (d *Debugger) Resume(mode StepMode) {
+	d.mode = mode
+}
+
+// Quit ends the script once Stopped returns.
+func (d *Debugger) Quit() {
+	d.quit = true
+}
+
+// File returns the path of the script being debugged.
+func (d *Debugger) File() string {
+	return d.file
+}
+
+// Line returns the line the script stopped at.
+func (d *Debugger) Line() int {
+	return d.line
+}
+
+// SourceLine returns the text of line n of the script.
+func (d *Debugger) SourceLine(n int) (string, bool) {
+	return d.source.Line(n)
+}
+
+func (d *Debugger) hook(s Stmt) {
+	if _, ok := s.(*Block); ok {
+		// A block starts every iteration of a loop, which lets a breakpoint
+		// on its first line stop again.
+		d.prevLine = 0
+	}
+
+	line, ok := d.lines[s]
+	if !ok {
+		return
+	}
+
+	i := d.vm.interpreter
+	depth := len(i.frames)
+	d.track(depth, i.env)
+
+	entered := line != d.prevLine || depth != d.prevDepth
+	d.prevLine, d.prevDepth = line, depth
+	if !entered {
+		return
+	}
+
+	d.mu.Lock()
+	breakpoint := d.breakpoints[line]
+	d.mu.Unlock()
+
+	var reason StopReason
+	switch {
+	case d.entry:
+		reason = StopEntry
+	case breakpoint:
+		reason = StopBreakpoint
+	case d.mode == StepIn,
+		d.mode == StepOver && depth <= d.depth,
+		d.mode == StepOut && depth < d.depth:
+		reason = StopStep
+	default:
+		return
+	}
+
+	d.entry = false
+	d.line, d.depth = line, depth
+	d.mode = StepContinue
+	if d.Stopped != nil {
+		d.Stopped(reason)
+	}
+
+	if d.quit {
+		panic(debugQuit{})
+	}
+}
+
+// track records env as the environment of the call at depth.
+func (d *Debugger) track(depth int, env *Environment) {
+	if len(d.envs) > depth {
+		d.envs = d.envs[:depth]
+	}
+	for len(d.envs) < depth {
+		d.envs = append(d.envs, nil)
+	}
+	d.envs = append(d.envs, env)
+}
+
+// Frames returns the active calls, innermost first.
+func (d *Debugger) Frames() []DebugFrame {
+	calls := d.vm.interpreter.frames
+
+	frames := make([]DebugFrame, 0, len(calls)+1)
+	line := d.line
+	for n := len(calls); n > 0; n-- {
+		frames = append(frames, DebugFrame{Function: calls[n-1].Function, Line: line, Native: calls[n-1].Native})
+		line = calls[n-1].Call.Line
+	}
+
+	return append(frames, DebugFrame{Function: "<script>", Line: line})
+}
+
+// frameEnv returns the innermost environment of frame, counted from the
+// innermost call, or nil for native frames.
+func (d *Debugger) frameEnv(frame int) *Environment {
+	depth := len(d.vm.interpreter.frames) - frame
+	if frame < 0 || depth < 0 || depth >= len(d.envs) {
+		return nil
+	}
+	return d.envs[depth]
+}
+
+// Locals returns the variables frame sees outside the globals, inner ones
+// hiding outer ones of the same name.
+func (d *Debugger) Locals(frame int) []DebugVariable {
+	seen := make(map[string]bool)
+	var vars []DebugVariable
+	for env := d.frameEnv(frame); env != nil && env.enclosing != nil; env = env.enclosing {
+		for name, value := range env.values {
+			if !seen[name] {
+				seen[name] = true
+				vars = append(vars, DebugVariable{Name: name, Value: value})
+			}
+		}
+	}
+
+	return sortVariables(vars)
+}
+
+// Globals returns the globals of frame, except the built-ins.
+func (d *Debugger) Globals(frame int) []DebugVariable {
+	env := d.frameEnv(frame)
+	if env == nil {
+		return nil
+	}
+
+	natives := d.vm.interpreter.natives
+	var vars []DebugVariable
+	for name, value := range env.global().values {
+		if native, ok := natives[name]; !ok || native != value {
+			vars = append(vars, DebugVariable{Name: name, Value: value})
+		}
+	}
+
+	return sortVariables(vars)
+}
+
+// Scopes returns the environments frame sees, from its innermost block out
+// to the globals.
+func (d *Debugger) Scopes(frame int) []DebugScope {
+	var scopes []DebugScope
+	for env := d.frameEnv(frame); env != nil; env = env.enclosing {
+		switch {
+		case env.enclosing == nil:
+			scopes = append(scopes, DebugScope{Name: "Globals", Variables: d.Globals(frame)})
+		default:
+			name := "Enclosing"
+			if len(scopes) == 0 {
+				name = "Locals"
+			}
+			scopes = append(scopes, DebugScope{Name: name, Variables: envVariables(env)})
+		}
+	}
+
+	return scopes
+}
+
+// Children returns the elements of a list, the entries of a map or the
+// fields of an instance, and nil for other values.
+func (d *Debugger) Children(value Value) []DebugVariable {
+	var vars []DebugVariable
+	switch v := value.(type) {
+	case *List:
+		for n, elem := range v.Values() {
+			vars = append(vars, DebugVariable{Name: fmt.Sprintf("[%d]", n), Value: elem})
+		}
+	case *Map:
+		for _, key := range v.Keys() {
+			elem, _ := v.Get(key)
+			vars = append(vars, DebugVariable{Name: FormatValue(key), Value: elem})
+		}
+	case *loxInstance[any]:
+		for name, field := range v.fields {
+			vars = append(vars, DebugVariable{Name: name, Value: field})
+		}
+		sortVariables(vars)
+	}
+
+	return vars
+}
+
+// FormatValue formats value the way the debugger shows it, with strings
+// quoted.
+func FormatValue(value Value) string {
+	return formatNested(value, make(map[any]bool))
+}
+
+func envVariables(env *Environment) []DebugVariable {
+	vars := make([]DebugVariable, 0, len(env.values))
+	for name, value := range env.values {
+		vars = append(vars, DebugVariable{Name: name, Value: value})
+	}
+	return sortVariables(vars)
+}
+
+func sortVariables(vars []DebugVariable) []DebugVariable {
+	sort.Slice(vars, func(a, b int) bool {
+		return vars[a].Name < vars[b].Name
+	})
+	return vars
+}
+
+// Evaluate evaluates the expression src in the scope of frame. Assignments
+// change the variables of the frame.
+func (d *Debugger) Evaluate(frame int, src string) (value Value, err error) {
+	env := d.frameEnv(frame)
+	if env == nil {
+		return nil, errors.New("frame has no variables")
+	}
+
+	src = strings.TrimSpace(src)
+	if !strings.HasSuffix(src, ";") {
+		src += ";"
+	}
+
+	stmts, diags := Parse("", src)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if len(stmts) != 1 {
+		return nil, errors.New("expected an expression")
+	}
+	expr, ok := stmts[0].(*Expression)
+	if !ok {
+		return nil, errors.New("expected an expression")
+	}
+
+	// The expression is not resolved, so it runs in a single scope holding
+	// every variable the frame sees.
+	var chain []*Environment
+	for e := env; e != nil; e = e.enclosing {
+		chain = append(chain, e)
+	}
+
+	scope := NewEnvironment(nil)
+	owners := make(map[string]*Environment)
+	for n := len(chain) - 1; n >= 0; n-- {
+		for name, value := range chain[n].values {
+			scope.values[name] = value
+			owners[name] = chain[n]
+		}
+	}
+
+	i := d.vm.interpreter
+	prevEnv, prevHook := i.env, i.debugHook
+	i.env, i.debugHook = scope, nil
+	defer func() {
+		i.env, i.debugHook = prevEnv, prevHook
+		for name, owner := range owners {
+			owner.values[name] = scope.values[name]
+		}
+
+		if r := recover(); r != nil {
+			var runtimeErr RuntimeError
+			if e, ok := r.(error); ok && errors.As(e, &runtimeErr) {
+				err = runtimeErr
+			} else if haltErr, ok := r.(haltError); ok {
+				err = haltErr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	return i.evaluate(expr.Expression), nil
+}
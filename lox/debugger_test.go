@@ -0,0 +1,164 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const debugScript = `fun square(x) {
+  var result = x * x;
+  return result;
+}
+var total = 0;
+for (var i = 1; i <= 2; i = i + 1) {
+  total = total + square(i);
+}
+var squares = map([3], square);
+print total;
+`
+
+func Test_Debug(t *testing.T) {
+	testCases := []struct {
+		name     string
+		commands string
+		want     string
+	}{
+		{
+			name:     "run to the end",
+			commands: "c\n",
+			want: `Stopped at main.lox:1 (entry)
+   1 | fun square(x) {
+(lox) 5
+`,
+		},
+		{
+			name:     "breakpoints",
+			commands: "b 3\nb 11\nc\nlocals\nglobals\nc\nclear 3\nb 10\nc\nc\n",
+			want: `Stopped at main.lox:1 (entry)
+   1 | fun square(x) {
+(lox) Breakpoint at line 3.
+(lox) No statement on line 11.
+(lox) Stopped at main.lox:3 (breakpoint)
+   3 |   return result;
+(lox) result = 1
+x = 1
+(lox) square = <fn square>
+total = 0
+(lox) Stopped at main.lox:3 (breakpoint)
+   3 |   return result;
+(lox) Cleared breakpoint at line 3.
+(lox) Breakpoint at line 10.
+(lox) Stopped at main.lox:10 (breakpoint)
+  10 | print total;
+(lox) 5
+`,
+		},
+		{
+			name:     "stepping",
+			commands: "n\nn\nn\ns\ns\no\nn\nn\nc\n",
+			want: `Stopped at main.lox:1 (entry)
+   1 | fun square(x) {
+(lox) Stopped at main.lox:5 (step)
+   5 | var total = 0;
+(lox) Stopped at main.lox:6 (step)
+   6 | for (var i = 1; i <= 2; i = i + 1) {
+(lox) Stopped at main.lox:7 (step)
+   7 |   total = total + square(i);
+(lox) Stopped at main.lox:2 (step)
+   2 |   var result = x * x;
+(lox) Stopped at main.lox:3 (step)
+   3 |   return result;
+(lox) Stopped at main.lox:7 (step)
+   7 |   total = total + square(i);
+(lox) Stopped at main.lox:9 (step)
+   9 | var squares = map([3], square);
+(lox) Stopped at main.lox:10 (step)
+  10 | print total;
+(lox) 5
+`,
+		},
+		{
+			name:     "backtrace through a native",
+			commands: "b 2\nc\nc\nc\nbt\nc\n",
+			want: `Stopped at main.lox:1 (entry)
+   1 | fun square(x) {
+(lox) Breakpoint at line 2.
+(lox) Stopped at main.lox:2 (breakpoint)
+   2 |   var result = x * x;
+(lox) Stopped at main.lox:2 (breakpoint)
+   2 |   var result = x * x;
+(lox) Stopped at main.lox:2 (breakpoint)
+   2 |   var result = x * x;
+(lox) #0 square at main.lox:2
+#1 map [native]
+#2 <script> at main.lox:9
+(lox) 5
+`,
+		},
+		{
+			name:     "evaluate",
+			commands: "b 3\nc\np result * 10\np x = 5\np [x, \"s\"]\np missing\np (\np // x\nlocals\nc\nq\n",
+			want: `Stopped at main.lox:1 (entry)
+   1 | fun square(x) {
+(lox) Breakpoint at line 3.
+(lox) Stopped at main.lox:3 (breakpoint)
+   3 |   return result;
+(lox) 10
+(lox) 5
+(lox) [5, "s"]
+(lox) error: Undefined variable
+(lox) error: expect expression.
+(lox) error: expected an expression
+(lox) result = 1
+x = 5
+(lox) Stopped at main.lox:3 (breakpoint)
+   3 |   return result;
+(lox) `,
+		},
+		{
+			name:     "unknown command and end of input",
+			commands: "frobnicate\n",
+			want: "Stopped at main.lox:1 (entry)\n" +
+				"   1 | fun square(x) {\n" +
+				"(lox) Unknown command \"frobnicate\", type help for the list of commands.\n" +
+				"(lox) \n",
+		},
+	}
+
+	dir := writeModules(t, map[string]string{"main.lox": debugScript})
+	path := filepath.Join(dir, "main.lox")
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			vm := New(WithStdout(&out))
+
+			err := vm.Debug(context.Background(), path, strings.NewReader(tc.commands), &out)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, strings.ReplaceAll(out.String(), path, "main.lox"))
+		})
+	}
+}
+
+func Test_DebugErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"broken.lox":  "var = 1;",
+		"failing.lox": "print 1;\n1 / 0;",
+	})
+
+	_, err := NewDebugger(New(), filepath.Join(dir, "broken.lox"))
+	require.ErrorAs(t, err, &ParseError{})
+
+	var out bytes.Buffer
+	err = New(WithStdout(&out)).Debug(context.Background(), filepath.Join(dir, "failing.lox"), strings.NewReader("c\n"), &out)
+
+	var runtimeErr RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, "Division by zero", runtimeErr.Message)
+}
@@ -0,0 +1,346 @@
+package lox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type Source struct {
+	File  string
+	lines []string
+}
+
+func newSource(file, text string) *Source {
+	return &Source{
+		File:  file,
+		lines: strings.Split(text, "\n"),
+	}
+}
+
+func (s *Source) Line(n int) (string, bool) {
+	if s == nil || n < 1 || n > len(s.lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(s.lines[n-1], "\r"), true
+}
+
+type ErrorFormat string
+
+const (
+	ErrorFormatHuman ErrorFormat = "human"
+	ErrorFormatPlain ErrorFormat = "plain"
+	ErrorFormatJSON  ErrorFormat = "json"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Length   int      `json:"length,omitempty"`
+
+	Err    error `json:"-"`
+	source *Source
+	stack  []StackFrame
+}
+
+type DiagnosticList []Diagnostic
+
+var _ error = DiagnosticList{}
+
+func (l DiagnosticList) Error() string {
+	msgs := make([]string, 0, len(l))
+	for _, d := range l {
+		msgs = append(msgs, d.Err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l DiagnosticList) Unwrap() []error {
+	errs := make([]error, 0, len(l))
+	for _, d := range l {
+		errs = append(errs, d.Err)
+	}
+	return errs
+}
+
+func (l DiagnosticList) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func Diagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	var list DiagnosticList
+	if errors.As(err, &list) {
+		return list
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var diags []Diagnostic
+		for _, e := range joined.Unwrap() {
+			diags = append(diags, Diagnostics(e)...)
+		}
+		return diags
+	}
+
+	return []Diagnostic{newDiagnostic(err)}
+}
+
+// DiagnosticMessage joins the messages of the diagnostics of err into one
+// line, without their positions.
+func DiagnosticMessage(err error) string {
+	var msgs []string
+	for _, d := range Diagnostics(err) {
+		msgs = append(msgs, d.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func newDiagnostic(err error) Diagnostic {
+	var (
+		scanErr    ScanError
+		parseErr   ParseError
+		resolveErr ResolveError
+		compileErr CompileError
+		runtimeErr RuntimeError
+	)
+
+	switch {
+	case errors.As(err, &scanErr):
+		return newTokenDiagnostic(err, "scan error", scanErr.Token, scanErr.Message)
+	case errors.As(err, &parseErr):
+		return newTokenDiagnostic(err, "parse error", parseErr.Token, parseErr.Message)
+	case errors.As(err, &resolveErr):
+		return newTokenDiagnostic(err, "resolve error", resolveErr.Token, resolveErr.Message)
+	case errors.As(err, &compileErr):
+		return newTokenDiagnostic(err, "compile error", compileErr.Token, compileErr.Message)
+	case errors.As(err, &runtimeErr):
+		d := newTokenDiagnostic(err, "runtime error", runtimeErr.Token, runtimeErr.Message)
+		d.stack = runtimeErr.Stack
+		return d
+	default:
+		return Diagnostic{Severity: SeverityError, Kind: "error", Message: err.Error(), Err: err}
+	}
+}
+
+func newDiagnostics(errs []error) DiagnosticList {
+	diags := make(DiagnosticList, 0, len(errs))
+	for _, err := range errs {
+		diags = append(diags, newDiagnostic(err))
+	}
+	return diags
+}
+
+func newTokenDiagnostic(err error, kind string, token *Token, message string) Diagnostic {
+	d := Diagnostic{
+		Severity: SeverityError,
+		Kind:     kind,
+		Message:  message,
+		Line:     token.Line,
+		Column:   token.Column,
+		Err:      err,
+		source:   token.Source,
+	}
+
+	if token.Source != nil {
+		d.File = token.Source.File
+	}
+
+	if d.Column > 0 {
+		lexeme, _, _ := strings.Cut(token.Lexeme, "\n")
+		d.Length = max(utf8.RuneCountInString(lexeme), 1)
+	}
+
+	return d
+}
+
+func WriteErrors(w io.Writer, format ErrorFormat, err error) error {
+	for _, d := range Diagnostics(err) {
+		var werr error
+		switch format {
+		case ErrorFormatJSON:
+			werr = d.writeJSON(w)
+		case ErrorFormatPlain:
+			werr = d.writePlain(w)
+		default:
+			werr = d.writeHuman(w)
+		}
+		if werr != nil {
+			return werr
+		}
+	}
+
+	return nil
+}
+
+func displayFile(file string) string {
+	if file == "" {
+		return "<input>"
+	}
+	return file
+}
+
+func (d Diagnostic) location() string {
+	file := displayFile(d.File)
+
+	switch {
+	case d.Line == 0:
+		return file
+	case d.Column == 0:
+		return fmt.Sprintf("%s:%d", file, d.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", file, d.Line, d.Column)
+	}
+}
+
+func (d Diagnostic) header() string {
+	if d.Severity == SeverityError {
+		return d.Kind
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Kind)
+}
+
+func (d Diagnostic) writePlain(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s: %s: %s\n", d.location(), d.header(), d.Message)
+	return err
+}
+
+func (d Diagnostic) writeJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(d)
+}
+
+// recursiveCutoff is how many times in a row a traceback shows the same
+// entry before it only counts the rest, as Python does.
+const recursiveCutoff = 3
+
+// writeTraceback writes the calls that led to the error, most recent call
+// last.
+func (d Diagnostic) writeTraceback(b *strings.Builder) {
+	b.WriteString("Traceback (most recent call last):\n")
+
+	var last string
+	count := 0
+	write := func(frame StackFrame, source *Source, line int) {
+		var entry strings.Builder
+		writeFrame(&entry, frame, source, line)
+		if entry.String() != last {
+			writeRepeats(b, count)
+			last, count = entry.String(), 0
+		}
+
+		if count++; count <= recursiveCutoff {
+			b.WriteString(last)
+		}
+	}
+
+	caller := StackFrame{Function: "<script>"}
+	for _, frame := range d.stack {
+		write(caller, frame.Call.Source, frame.Call.Line)
+		caller = frame
+	}
+	write(caller, d.source, d.Line)
+	writeRepeats(b, count)
+}
+
+func writeRepeats(b *strings.Builder, count int) {
+	switch n := count - recursiveCutoff; {
+	case n == 1:
+		b.WriteString("  [Previous line repeated 1 more time]\n")
+	case n > 1:
+		fmt.Fprintf(b, "  [Previous line repeated %d more times]\n", n)
+	}
+}
+
+// writeFrame writes the entry of frame in a traceback, which is executing
+// the given line.
+func writeFrame(b *strings.Builder, frame StackFrame, source *Source, line int) {
+	if frame.Native {
+		fmt.Fprintf(b, "  [native] in %s\n", frame.Function)
+		return
+	}
+
+	var file string
+	if source != nil {
+		file = source.File
+	}
+	fmt.Fprintf(b, "  File \"%s\", line %d, in %s\n", displayFile(file), line, frame.Function)
+
+	if text, ok := source.Line(line); ok {
+		fmt.Fprintf(b, "    %s\n", strings.TrimSpace(text))
+	}
+}
+
+func (d Diagnostic) writeHuman(w io.Writer) error {
+	var b strings.Builder
+	if len(d.stack) > 0 {
+		d.writeTraceback(&b)
+	}
+	fmt.Fprintf(&b, "%s: %s\n", d.header(), d.Message)
+
+	line, ok := d.source.Line(d.Line)
+	if !ok {
+		if d.Line > 0 {
+			fmt.Fprintf(&b, " --> %s\n", d.location())
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	number := fmt.Sprintf("%d", d.Line)
+	gutter := strings.Repeat(" ", len(number))
+
+	fmt.Fprintf(&b, "%s--> %s\n", gutter, d.location())
+	fmt.Fprintf(&b, "%s |\n", gutter)
+	fmt.Fprintf(&b, "%s | %s\n", number, line)
+	if d.Column > 0 {
+		prefix := []rune(line)[:min(d.Column-1, utf8.RuneCountInString(line))]
+		indent := strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return '\t'
+			}
+			return ' '
+		}, string(prefix))
+		length := max(min(d.Length, utf8.RuneCountInString(line)-d.Column+1), 1)
+		fmt.Fprintf(&b, "%s | %s^%s\n", gutter, indent, strings.Repeat("~", length-1))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,98 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		format ErrorFormat
+		want   string
+	}{
+		{
+			name:   "human",
+			input:  "var answer = 42;\nprint answer + nothing;",
+			format: ErrorFormatHuman,
+			want: "runtime error: Undefined variable\n" +
+				" --> <input>:2:16\n" +
+				"  |\n" +
+				"2 | print answer + nothing;\n" +
+				"  |                ^~~~~~~\n",
+		},
+		{
+			name:   "traceback",
+			input:  "fun half(x) {\n  return sqrt(x) / 2;\n}\nhalf(-4);",
+			format: ErrorFormatHuman,
+			want: "Traceback (most recent call last):\n" +
+				"  File \"<input>\", line 4, in <script>\n" +
+				"    half(-4);\n" +
+				"  File \"<input>\", line 2, in half\n" +
+				"    return sqrt(x) / 2;\n" +
+				"  [native] in sqrt\n" +
+				"runtime error: sqrt: expected non-negative number, got -4.\n" +
+				" --> <input>:2:16\n" +
+				"  |\n" +
+				"2 |   return sqrt(x) / 2;\n" +
+				"  |                ^\n",
+		},
+		{
+			name:   "superclass is not a class",
+			input:  "var B = 1;\nclass A < B {}",
+			format: ErrorFormatHuman,
+			want: "runtime error: Superclass must be a class.\n" +
+				" --> <input>:2:11\n" +
+				"  |\n" +
+				"2 | class A < B {}\n" +
+				"  |           ^\n",
+		},
+		{
+			name:   "plain",
+			input:  "print 1;\nvar = 2;",
+			format: ErrorFormatPlain,
+			want:   "<input>:2:5: parse error: Expect variable name.\n",
+		},
+		{
+			name:   "source order",
+			input:  "print 1;\nvar = 2;\nprint 3 # 4;",
+			format: ErrorFormatPlain,
+			want: "<input>:2:5: parse error: Expect variable name.\n" +
+				"<input>:3:9: scan error: Unexpected character.\n" +
+				"<input>:3:11: parse error: Expect ';' after value.\n",
+		},
+		{
+			name:   "json",
+			input:  "print 1 # 2;",
+			format: ErrorFormatJSON,
+			want: `{"severity":"error","kind":"scan error","message":"Unexpected character.","line":1,"column":9,"length":1}` + "\n" +
+				`{"severity":"error","kind":"parse error","message":"Expect ';' after value.","line":1,"column":11,"length":1}` + "\n",
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				_, err := New(WithBackend(backend)).Eval(context.Background(), tc.input)
+				require.Error(t, err)
+
+				var buf bytes.Buffer
+				require.NoError(t, WriteErrors(&buf, tc.format, err))
+				assert.Equal(t, tc.want, buf.String())
+			})
+		}
+	}
+}
+
+func Test_DiagnosticMessage(t *testing.T) {
+	_, err := New().Eval(context.Background(), "print 1 # 2;")
+	assert.Equal(t, "Unexpected character.; Expect ';' after value.", DiagnosticMessage(err))
+
+	_, err = New().Eval(context.Background(), "print nothing;")
+	assert.Equal(t, "Undefined variable", DiagnosticMessage(err))
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Environment struct {
 	enclosing *Environment
@@ -42,3 +42,31 @@ func (e *Environment) Get(key *Token) interface{} {
 
 	return val
 }
+
+// global returns the outermost environment, which holds the globals of the
+// module the chain belongs to.
+func (e *Environment) global() *Environment {
+	env := e
+	for env.enclosing != nil {
+		env = env.enclosing
+	}
+
+	return env
+}
+
+func (e *Environment) GetAt(distance int, key *Token) interface{} {
+	return e.ancestor(distance).values[key.Lexeme]
+}
+
+func (e *Environment) AssignAt(distance int, key *Token, value interface{}) {
+	e.ancestor(distance).values[key.Lexeme] = value
+}
+
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for n := 0; n < distance; n++ {
+		env = env.enclosing
+	}
+
+	return env
+}
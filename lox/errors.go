@@ -0,0 +1,199 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type ScanError struct {
+	Token   *Token
+	Message string
+}
+
+var _ error = ScanError{}
+
+func NewScanError(token *Token, message string) error {
+	return ScanError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e ScanError) Error() string {
+	return fmt.Sprintf(
+		"[%d]: scan error at %q: %s",
+		e.Token.Line,
+		e.Token.Lexeme,
+		e.Message,
+	)
+}
+
+type ParseError struct {
+	Token   *Token
+	Message string
+}
+
+var _ error = ParseError{}
+
+func NewParseError(token *Token, message string) error {
+	return ParseError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf(
+		"[%d]: parse error at %s %s: %s",
+		e.Token.Line,
+		e.Token.Type,
+		e.Token.Lexeme,
+		e.Message,
+	)
+}
+
+type ResolveError struct {
+	Token   *Token
+	Message string
+}
+
+var _ error = ResolveError{}
+
+func NewResolveError(token *Token, message string) error {
+	return ResolveError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e ResolveError) Error() string {
+	return fmt.Sprintf(
+		"[%d]: resolve error at %s %s: %s",
+		e.Token.Line,
+		e.Token.Type,
+		e.Token.Lexeme,
+		e.Message,
+	)
+}
+
+type CompileError struct {
+	Token   *Token
+	Message string
+}
+
+var _ error = CompileError{}
+
+func NewCompileError(token *Token, message string) error {
+	return CompileError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func (e CompileError) Error() string {
+	return fmt.Sprintf(
+		"[%d]: compile error at %s %s: %s",
+		e.Token.Line,
+		e.Token.Type,
+		e.Token.Lexeme,
+		e.Message,
+	)
+}
+
+type RuntimeError struct {
+	Token   *Token
+	Message string
+	Err     error
+	// Value is the value of a throw statement, nil for errors raised by
+	// the runtime itself.
+	Value Value
+	// Stack holds the calls that were active when the error was raised,
+	// outermost first.
+	Stack []StackFrame
+}
+
+var _ error = ParseError{}
+
+func NewRuntimeError(token *Token, message string) error {
+	return RuntimeError{
+		Token:   token,
+		Message: message,
+	}
+}
+
+func NewNativeError(token *Token, err error) error {
+	return RuntimeError{
+		Token:   token,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+// NewThrowError raises value from a throw statement. Rethrowing a caught
+// runtime error raises the original error again.
+func NewThrowError(token *Token, value Value) error {
+	if e, ok := value.(*Error); ok {
+		return e.err
+	}
+
+	return RuntimeError{
+		Token:   token,
+		Message: fmt.Sprintf("%v", value),
+		Value:   value,
+	}
+}
+
+// ExitError ends a script that called exit with the given status code.
+type ExitError struct {
+	Code int
+}
+
+var _ error = ExitError{}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// StackFrame is an active call of a function: its name and the token of the
+// call. Native frames are calls of built-ins.
+type StackFrame struct {
+	Function string
+	Call     *Token
+	Native   bool
+}
+
+// CancelError stops a script whose context was cancelled. Stack holds the
+// calls that were active when it stopped, outermost first.
+type CancelError struct {
+	Err   error
+	Stack []StackFrame
+}
+
+var _ error = CancelError{}
+
+func (e CancelError) Error() string {
+	return fmt.Sprintf("script cancelled: %s", e.Err)
+}
+
+func (e CancelError) Unwrap() error {
+	return e.Err
+}
+
+func (CancelError) halt() {}
+
+func (e RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+func (e RuntimeError) Error() string {
+	if e.Token.Type == EOF {
+		return fmt.Sprintf("[%d]: runtime error: %s", e.Token.Line, e.Message)
+	}
+
+	return fmt.Sprintf(
+		"[%d]: runtime error at %s %s: %s",
+		e.Token.Line,
+		e.Token.Type,
+		e.Token.Lexeme,
+		e.Message,
+	)
+}
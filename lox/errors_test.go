@@ -0,0 +1,196 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Exceptions(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "catch runtime error",
+			input: "var m; try { 1 / 0; } catch (e) { m = [e.message, e.line]; } m;",
+			want:  `["Division by zero", 1]`,
+		},
+		{
+			name:  "catch undefined variable",
+			input: "var m; try { missing; } catch (e) { m = e.message; } m;",
+			want:  "Undefined variable",
+		},
+		{
+			name:  "catch arity error",
+			input: "fun f(a) {} var m; try { f(); } catch (e) { m = e.message; } m;",
+			want:  "Expected 1 arguments but got 0.",
+		},
+		{
+			name:  "throw any value",
+			input: `var v; try { throw [1, "a"]; } catch (e) { v = e; } v;`,
+			want:  `[1, "a"]`,
+		},
+		{
+			name:  "throw across calls",
+			input: `fun f(n) { if (n == 0) throw "deep"; return f(n - 1); } var v; try { f(5); } catch (e) { v = e; } v;`,
+			want:  "deep",
+		},
+		{
+			name:  "rethrow keeps error",
+			input: "var v; try { try { 1 / 0; } catch (e) { throw e; } } catch (e) { v = e.message; } v;",
+			want:  "Division by zero",
+		},
+		{
+			name:  "finally on normal exit",
+			input: `var s = ""; try { s = s + "t"; } finally { s = s + "f"; } s;`,
+			want:  "tf",
+		},
+		{
+			name:  "finally on error",
+			input: `var s = ""; try { try { throw 1; } finally { s = s + "f"; } } catch (e) { s = s + "c"; } s;`,
+			want:  "fc",
+		},
+		{
+			name:  "finally on return",
+			input: `var s = ""; fun f() { try { return 1; } finally { s = s + "f"; } } f(); [s, f(), s];`,
+			want:  `["f", 1, "ff"]`,
+		},
+		{
+			name:  "finally on break",
+			input: `var s = ""; while (true) { try { break; } finally { s = s + "f"; } } s;`,
+			want:  "f",
+		},
+		{
+			name:  "catch inside loop with closure",
+			input: `var fs = []; for (var i = 0; i < 3; i = i + 1) { try { throw i; } catch (e) { fun f() { return e; } push(fs, f); } } fs[0]() + fs[2]();`,
+			want:  "2",
+		},
+		{
+			name:  "catch from native callback",
+			input: `var v; try { fun inv(x) { return 1 / x; } map([1, 0], inv); } catch (e) { v = e.message; } v;`,
+			want:  "Division by zero",
+		},
+		{
+			name:    "uncaught throw",
+			input:   `throw "boom";`,
+			wantErr: "boom",
+		},
+		{
+			name:    "missing catch and finally",
+			input:   "try {}",
+			wantErr: "parse error",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_ThrowErrorValue(t *testing.T) {
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			_, err := New(WithBackend(backend)).Eval(context.Background(), "\nthrow {\"code\": 1};")
+
+			var runtimeErr RuntimeError
+			require.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, 2, runtimeErr.Token.Line)
+			assert.Equal(t, `{"code": 1}`, runtimeErr.Message)
+			assert.IsType(t, &Map{}, runtimeErr.Value)
+		})
+	}
+}
+
+func Test_StackTrace(t *testing.T) {
+	testCases := []struct {
+		name          string
+		limits        Limits
+		input         string
+		wantStack     []string
+		wantTraceback string
+	}{
+		{
+			name:  "top level",
+			input: "1 / 0;",
+		},
+		{
+			name: "nested calls",
+			input: `fun inner() { return 1 / 0; }
+fun outer() {
+  return inner();
+}
+outer();`,
+			wantStack: []string{"outer:5", "inner:3"},
+		},
+		{
+			name: "native frames",
+			input: `class Box {
+  init(value) { this.value = sqrt(value); }
+}
+map([4, -1], (x) => Box(x));`,
+			wantStack: []string{"map:4 [native]", "anonymous@4:4", "init:4", "sqrt:2 [native]"},
+		},
+		{
+			name: "rethrown error keeps its stack",
+			input: `fun fail() { missing; }
+try { fail(); } catch (e) {
+  throw e;
+}`,
+			wantStack: []string{"fail:2"},
+		},
+		{
+			name: "caught errors unwind native frames",
+			input: `fun fail(x) { throw "no"; }
+fun safe() { try { map([1], fail); } catch (e) {} }
+safe();
+fun boom() { 1 / 0; }
+boom();`,
+			wantStack: []string{"boom:5"},
+		},
+		{
+			name:      "stack overflow",
+			limits:    Limits{MaxCallDepth: 6},
+			input:     "fun f() {\n  f();\n}\nf();",
+			wantStack: []string{"f:4", "f:2", "f:2", "f:2", "f:2", "f:2"},
+			wantTraceback: "Traceback (most recent call last):\n" +
+				"  File \"<input>\", line 4, in <script>\n" +
+				"    f();\n" +
+				"  File \"<input>\", line 2, in f\n" +
+				"    f();\n" +
+				"  File \"<input>\", line 2, in f\n" +
+				"    f();\n" +
+				"  File \"<input>\", line 2, in f\n" +
+				"    f();\n" +
+				"  [Previous line repeated 3 more times]\n" +
+				"runtime error: Stack overflow.\n",
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				_, err := New(WithBackend(backend), WithLimits(tc.limits)).Eval(context.Background(), tc.input)
+
+				var runtimeErr RuntimeError
+				require.ErrorAs(t, err, &runtimeErr)
+
+				var stack []string
+				for _, frame := range runtimeErr.Stack {
+					entry := fmt.Sprintf("%s:%d", frame.Function, frame.Call.Line)
+					if frame.Native {
+						entry += " [native]"
+					}
+					stack = append(stack, entry)
+				}
+				assert.Equal(t, tc.wantStack, stack)
+
+				if tc.wantTraceback != "" {
+					var buf bytes.Buffer
+					require.NoError(t, WriteErrors(&buf, ErrorFormatHuman, err))
+					assert.True(t, strings.HasPrefix(buf.String(), tc.wantTraceback), buf.String())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package lox
+
+import (
+	"strconv"
+	"strings"
+)
+
+const indentUnit = "  "
+
+// Format parses src and prints it back as canonical Lox, keeping comments.
+// Formatting its own output again returns the same text.
+func Format(file, src string) (string, error) {
+	tokens, stmts, diags := parse(file, src)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	f := &formatter{layout: newLayout(tokens, stmts), buf: &strings.Builder{}}
+	for _, t := range tokens {
+		f.comments = append(f.comments, t.Comments...)
+	}
+
+	f.list(stmts, tokens[len(tokens)-1])
+
+	return f.buf.String(), nil
+}
+
+type formatter struct {
+	layout   *layout
+	comments []*Token
+	next     int
+
+	buf     *strings.Builder
+	indent  int
+	prevEnd int
+}
+
+func (f *formatter) write(s ...string) {
+	for _, str := range s {
+		f.buf.WriteString(str)
+	}
+}
+
+func (f *formatter) writeIndent() {
+	f.write(strings.Repeat(indentUnit, f.indent))
+}
+
+func (f *formatter) separate(line int) {
+	if f.prevEnd > 0 && line > f.prevEnd+1 {
+		f.write("\n")
+	}
+}
+
+func (f *formatter) pending(offset int) bool {
+	return f.next < len(f.comments) && f.comments[f.next].Offset < offset
+}
+
+func (f *formatter) flushComments(offset int) {
+	for f.pending(offset) {
+		c := f.comments[f.next]
+		f.next++
+
+		f.separate(c.Line)
+		f.writeIndent()
+		f.write(commentText(c), "\n")
+		f.prevEnd = commentEnd(c)
+	}
+}
+
+func (f *formatter) list(stmts []Stmt, end *Token) {
+	f.prevEnd = 0
+	for _, stmt := range stmts {
+		f.item(stmt)
+		f.write("\n")
+	}
+	f.flushComments(end.Offset)
+}
+
+// item prints a statement on its own lines together with the comments in
+// front of it and the comments trailing it on its last line. Comments within
+// the statement that no token of it took follow it too.
+func (f *formatter) item(stmt Stmt) {
+	sp := f.layout.spans[stmt]
+
+	f.flushComments(sp.start.Offset)
+	f.separate(sp.start.Line)
+	f.writeIndent()
+	f.stmt(stmt)
+
+	// A comment after the statement trails it only if no other token comes
+	// between them, such as the brace closing the block around it.
+	next := f.layout.next(sp.end)
+	f.prevEnd = sp.end.Line
+	for broken := false; f.next < len(f.comments); {
+		c := f.comments[f.next]
+		if c.Offset > sp.end.Offset && (c.Line != sp.end.Line || c.Offset > next.Offset) {
+			break
+		}
+		f.next++
+
+		if broken {
+			f.write("\n")
+			f.writeIndent()
+		} else {
+			f.write(" ")
+		}
+		f.write(commentText(c))
+		f.prevEnd = commentEnd(c)
+		broken = isLineComment(c)
+	}
+}
+
+// inline returns the comments in front of t within an expression, so that
+// they stay between the tokens they were written between. A line comment
+// breaks the expression, which goes on indented unless t closes a bracket.
+func (f *formatter) inline(t *Token, closing bool) string {
+	var b strings.Builder
+	broken := false
+	for f.pending(t.Offset) {
+		c := f.comments[f.next]
+		f.next++
+
+		if closing && !broken {
+			b.WriteString(" ")
+		}
+		b.WriteString(commentText(c))
+
+		broken = isLineComment(c)
+		switch {
+		case broken && closing:
+			b.WriteString("\n" + strings.Repeat(indentUnit, f.indent))
+		case broken:
+			b.WriteString("\n" + strings.Repeat(indentUnit, f.indent+1))
+		case !closing:
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+func (f *formatter) stmt(stmt Stmt) {
+	if loop, ok := f.layout.loops[stmt]; ok {
+		f.forLoop(loop, f.layout.paren(stmt))
+		return
+	}
+	AcceptStmtVisitor[string](stmt, f)
+}
+
+func (f *formatter) block(stmts []Stmt, end *Token) {
+	if len(stmts) == 0 && !f.pending(end.Offset) {
+		f.write("{}")
+		return
+	}
+
+	f.write("{\n")
+	f.indent++
+	f.list(stmts, end)
+	f.indent--
+	f.writeIndent()
+	f.write("}")
+}
+
+// body prints the body of a control flow statement. It reports whether the
+// body ended with a closing brace on the current line.
+func (f *formatter) body(stmt Stmt) bool {
+	if b, ok := stmt.(*Block); ok && f.layout.loops[stmt] == nil {
+		f.write(" ")
+		f.block(b.Statements, f.layout.spans[b].end)
+		return true
+	}
+
+	f.write("\n")
+	f.indent++
+	f.prevEnd = 0
+	f.item(stmt)
+	f.indent--
+	return false
+}
+
+func (f *formatter) forLoop(loop *forLoop, paren *Token) {
+	f.write("for (")
+	if loop.Initializer != nil {
+		f.stmt(loop.Initializer)
+	} else {
+		f.write(";")
+	}
+	if loop.Condition != nil {
+		f.write(" ", f.expr(loop.Condition))
+	}
+	f.write(";")
+	if loop.Increment != nil {
+		f.write(" ", f.expr(loop.Increment))
+	}
+	f.write(f.inline(paren, true), ")")
+	f.body(loop.Body)
+}
+
+func (f *formatter) function(fn *Function) {
+	f.write(fn.Name.Lexeme, "(", params(fn), ") ")
+	f.block(fn.Body, f.layout.spans[fn].end)
+}
+
+func params(fn *Function) string {
+	names := make([]string, 0, len(fn.Params))
+	for _, param := range fn.Params {
+		names = append(names, param.Lexeme)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (f *formatter) expr(expr Expr) string {
+	return AcceptExprVisitor[string](expr, f)
+}
+
+func (f *formatter) VisitBlockStmt(s *Block) {
+	f.block(s.Statements, f.layout.spans[s].end)
+}
+
+func (f *formatter) VisitClassStmt(s *Class) {
+	f.write("class ", s.Name.Lexeme)
+	if s.Superclass != nil {
+		f.write(" < ", s.Superclass.Name.Lexeme)
+	}
+	f.write(" ")
+
+	methods := make([]Stmt, 0, len(s.Methods))
+	for _, m := range s.Methods {
+		methods = append(methods, m)
+	}
+	f.block(methods, f.layout.spans[s].end)
+}
+
+func (f *formatter) VisitExpressionStmt(s *Expression) {
+	f.write(f.expr(s.Expression), ";")
+}
+
+func (f *formatter) VisitFunctionStmt(s *Function) {
+	if f.layout.spans[s].start.Type == FUN {
+		f.write("fun ")
+	}
+	f.function(s)
+}
+
+func (f *formatter) VisitIfStmt(s *If) {
+	f.write("if (", f.expr(s.Expression), f.inline(f.layout.paren(s), true), ")")
+	closed := f.body(s.ThenBranch)
+	if s.ElseBranch == nil {
+		return
+	}
+
+	if closed {
+		f.write(" else")
+	} else {
+		f.write("\n")
+		f.writeIndent()
+		f.write("else")
+	}
+
+	if elseIf, ok := s.ElseBranch.(*If); ok {
+		f.write(" ")
+		f.VisitIfStmt(elseIf)
+		return
+	}
+
+	f.body(s.ElseBranch)
+}
+
+func (f *formatter) VisitPrintStmt(s *Print) {
+	f.write("print ", f.expr(s.Expression), ";")
+}
+
+func (f *formatter) VisitReturnStmt(s *Return) {
+	if _, ok := s.Value.(*NilT); ok {
+		f.write("return;")
+		return
+	}
+	f.write("return ", f.expr(s.Value), ";")
+}
+
+func (f *formatter) VisitVarStmt(s *Var) {
+	if s.Initializer == nil {
+		f.write("var ", s.Name.Lexeme, ";")
+		return
+	}
+	f.write("var ", f.inline(s.Name, false), s.Name.Lexeme, " = ", f.expr(s.Initializer), ";")
+}
+
+func (f *formatter) VisitWhileStmt(s *While) {
+	f.write("while (", f.expr(s.Condition), f.inline(f.layout.paren(s), true), ")")
+	f.body(s.Body)
+}
+
+func (f *formatter) VisitImportStmt(s *Import) {
+	f.write("import ", s.Path.Lexeme, " as ", s.Name.Lexeme, ";")
+}
+
+func (f *formatter) VisitThrowStmt(s *Throw) {
+	f.write("throw ", f.expr(s.Value), ";")
+}
+
+func (f *formatter) VisitTryStmt(s *Try) {
+	f.write("try ")
+	f.VisitBlockStmt(s.Body)
+	if s.Catch != nil {
+		f.write(" catch (", s.CatchName.Lexeme, ") ")
+		f.VisitBlockStmt(s.Catch)
+	}
+	if s.Finally != nil {
+		f.write(" finally ")
+		f.VisitBlockStmt(s.Finally)
+	}
+}
+
+func (f *formatter) VisitBreakStmt(s *Break) {
+	f.write("break;")
+}
+
+func (f *formatter) VisitContinueStmt(s *Continue) {
+	f.write("continue;")
+}
+
+func (f *formatter) VisitAssignExpr(e *Assign) string {
+	return f.inline(e.Name, false) + e.Name.Lexeme + " = " + f.expr(e.Value)
+}
+
+func (f *formatter) VisitBinaryExpr(e *Binary) string {
+	return f.expr(e.Left) + " " + f.inline(e.Operator, false) + e.Operator.Lexeme + " " + f.expr(e.Right)
+}
+
+func (f *formatter) VisitCallExpr(e *Call) string {
+	callee := f.expr(e.Callee)
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, f.expr(arg))
+	}
+	return callee + "(" + strings.Join(args, ", ") + f.inline(e.Paren, true) + ")"
+}
+
+func (f *formatter) VisitGetExpr(e *Get) string {
+	return f.expr(e.Object) + "." + f.inline(e.Name, false) + e.Name.Lexeme
+}
+
+func (f *formatter) VisitGroupingExpr(e *Grouping) string {
+	return "(" + f.expr(e.Expression) + ")"
+}
+
+func (f *formatter) VisitLiteralExpr(e *Literal) string {
+	if e.Token == nil {
+		return f.value(e.Value)
+	}
+	if _, ok := e.Value.(string); ok {
+		return f.inline(e.Token, false) + e.Token.Lexeme
+	}
+	return f.inline(e.Token, false) + f.value(e.Value)
+}
+
+func (f *formatter) value(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return "nil"
+	}
+}
+
+func (f *formatter) VisitInterpolationExpr(e *Interpolation) string {
+	var b strings.Builder
+	for n, s := range e.Strings {
+		b.WriteString(f.inline(s, n > 0))
+		b.WriteString(s.Lexeme)
+		if n < len(e.Exprs) {
+			b.WriteString(f.expr(e.Exprs[n]))
+		}
+	}
+	return b.String()
+}
+
+func (f *formatter) VisitLogicalExpr(e *Logical) string {
+	return f.expr(e.Left) + " " + f.inline(e.Operator, false) + e.Operator.Lexeme + " " + f.expr(e.Right)
+}
+
+func (f *formatter) VisitSetExpr(e *Set) string {
+	return f.expr(e.Object) + "." + f.inline(e.Name, false) + e.Name.Lexeme + " = " + f.expr(e.Value)
+}
+
+func (f *formatter) VisitLambdaExpr(e *Lambda) string {
+	fn := e.Function
+	if e.Keyword.Type == FUN {
+		return f.inline(e.Keyword, false) + "fun (" + params(fn) + ") " + f.lambdaBody(fn)
+	}
+
+	head := "(" + params(fn) + ") " + f.inline(e.Keyword, false) + "=> "
+	if ret, ok := fn.Body[0].(*Return); ok && len(fn.Body) == 1 && ret.Keyword == e.Keyword {
+		return head + f.expr(ret.Value)
+	}
+	return head + f.lambdaBody(fn)
+}
+
+// lambdaBody prints the block of a function expression, which spans lines
+// at the indentation of the enclosing statement.
+func (f *formatter) lambdaBody(fn *Function) string {
+	buf, prevEnd := f.buf, f.prevEnd
+	defer func() {
+		f.buf, f.prevEnd = buf, prevEnd
+	}()
+
+	f.buf = &strings.Builder{}
+	f.block(fn.Body, f.layout.spans[fn].end)
+	return f.buf.String()
+}
+
+func (f *formatter) VisitListLiteralExpr(e *ListLiteral) string {
+	open := f.inline(e.Bracket, false)
+	elements := make([]string, 0, len(e.Elements))
+	for _, element := range e.Elements {
+		elements = append(elements, f.expr(element))
+	}
+	return open + "[" + strings.Join(elements, ", ") + f.inline(f.layout.closer(e.Bracket), true) + "]"
+}
+
+func (f *formatter) VisitMapLiteralExpr(e *MapLiteral) string {
+	open := f.inline(e.Brace, false)
+	entries := make([]string, 0, len(e.Keys))
+	for n := range e.Keys {
+		entries = append(entries, f.expr(e.Keys[n])+": "+f.expr(e.Values[n]))
+	}
+	return open + "{" + strings.Join(entries, ", ") + f.inline(f.layout.closer(e.Brace), true) + "}"
+}
+
+func (f *formatter) VisitIndexExpr(e *Index) string {
+	return f.expr(e.Object) + "[" + f.expr(e.Index) + f.inline(f.layout.closer(e.Bracket), true) + "]"
+}
+
+func (f *formatter) VisitIndexSetExpr(e *IndexSet) string {
+	return f.expr(e.Object) + "[" + f.expr(e.Index) + f.inline(f.layout.closer(e.Bracket), true) + "] = " + f.expr(e.Value)
+}
+
+func (f *formatter) VisitSuperExpr(e *Super) string {
+	return f.inline(e.Keyword, false) + "super." + e.Method.Lexeme
+}
+
+func (f *formatter) VisitThisExpr(e *This) string {
+	return f.inline(e.Keyword, false) + "this"
+}
+
+func (f *formatter) VisitUnaryExpr(e *Unary) string {
+	operator := f.inline(e.Operator, false) + e.Operator.Lexeme
+	right := f.expr(e.Right)
+	// Keep two minus signs apart so they do not read as a decrement.
+	if strings.HasPrefix(right, "-") {
+		operator += " "
+	}
+	return operator + right
+}
+
+func (f *formatter) VisitVariableExpr(e *Variable) string {
+	return f.inline(e.Name, false) + e.Name.Lexeme
+}
+
+func isLineComment(c *Token) bool {
+	return strings.HasPrefix(c.Lexeme, "//")
+}
+
+func commentText(c *Token) string {
+	if isLineComment(c) {
+		return strings.TrimRight(c.Lexeme, " \t\r")
+	}
+	return c.Lexeme
+}
+
+func commentEnd(c *Token) int {
+	return c.Line + strings.Count(c.Lexeme, "\n")
+}
@@ -0,0 +1,187 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "spacing",
+			input: "var a=1+2*(3-4);print a;",
+			want:  "var a = 1 + 2 * (3 - 4);\nprint a;\n",
+		},
+		{
+			name:  "literals",
+			input: `print nil;print true;print 1.50;print 1000000;print "s";`,
+			want:  "print nil;\nprint true;\nprint 1.5;\nprint 1000000;\nprint \"s\";\n",
+		},
+		{
+			name:  "function",
+			input: "fun add(a,b){return a+b;} fun noop(){return;}",
+			want:  "fun add(a, b) {\n  return a + b;\n}\nfun noop() {\n  return;\n}\n",
+		},
+		{
+			name:  "class",
+			input: "class A<B{init(x){this.x=x;} get(){return super.get();}}",
+			want:  "class A < B {\n  init(x) {\n    this.x = x;\n  }\n  get() {\n    return super.get();\n  }\n}\n",
+		},
+		{
+			name:  "if else chain",
+			input: "if(a)print 1;else if(b){print 2;}else print 3;",
+			want:  "if (a)\n  print 1;\nelse if (b) {\n  print 2;\n} else\n  print 3;\n",
+		},
+		{
+			name:  "for loops",
+			input: "for(var i=0;i<3;i=i+1){print i;} for(;;){} for(i=0;;) print i;",
+			want:  "for (var i = 0; i < 3; i = i + 1) {\n  print i;\n}\nfor (;;) {}\nfor (i = 0;;)\n  print i;\n",
+		},
+		{
+			name:  "loop control",
+			input: "for(;;){if(a)break;continue;}",
+			want:  "for (;;) {\n  if (a)\n    break;\n  continue;\n}\n",
+		},
+		{
+			name:  "strings",
+			input: "print \"a\\n\\u{e9}${x+1}\";print `raw\nline`;",
+			want:  "print \"a\\n\\u{e9}${x + 1}\";\nprint `raw\nline`;\n",
+		},
+		{
+			name:  "lambdas",
+			input: "var f=x=>x*2;map(xs,fun(a){\n// keep\nreturn a;});var g=()=>{return;};",
+			want:  "var f = (x) => x * 2;\nmap(xs, fun (a) {\n  // keep\n  return a;\n});\nvar g = () => {\n  return;\n};\n",
+		},
+		{
+			name:  "import",
+			input: `import"lib/a.lox"as a;print a.b;`,
+			want:  "import \"lib/a.lox\" as a;\nprint a.b;\n",
+		},
+		{
+			name:  "try",
+			input: "try{f();}catch(e){print e.message;}finally{throw 1;}",
+			want:  "try {\n  f();\n} catch (e) {\n  print e.message;\n} finally {\n  throw 1;\n}\n",
+		},
+		{
+			name:  "while",
+			input: "while(!done and n>0)n=n-1;",
+			want:  "while (!done and n > 0)\n  n = n - 1;\n",
+		},
+		{
+			name:  "lists",
+			input: "var xs=[1,2,[3],];xs[0]=xs [1];",
+			want:  "var xs = [1, 2, [3]];\nxs[0] = xs[1];\n",
+		},
+		{
+			name:  "maps",
+			input: "var m={\"a\":1,2:[3],};m[\"a\"]=m [2];",
+			want:  "var m = {\"a\": 1, 2: [3]};\nm[\"a\"] = m[2];\n",
+		},
+		{
+			name:  "blank lines collapse",
+			input: "var a;\n\n\n\nvar b;\nvar c;",
+			want:  "var a;\n\nvar b;\nvar c;\n",
+		},
+		{
+			name:  "comments",
+			input: "// header\n\nvar a = 1;   // trailing\n/* block\n   comment */\nfun f() { // opening\n  print a;\n  // closing\n}\n// footer",
+			want:  "// header\n\nvar a = 1; // trailing\n/* block\n   comment */\nfun f() {\n  // opening\n  print a;\n  // closing\n}\n// footer\n",
+		},
+		{
+			name:  "comment in empty block",
+			input: "{ // nothing here\n}",
+			want:  "{\n  // nothing here\n}\n",
+		},
+		{
+			name:  "comment inside expression",
+			input: "var a = 1 + /* two */ 2;",
+			want:  "var a = 1 + /* two */ 2;\n",
+		},
+		{
+			name:  "comments inside list",
+			input: "var xs = [\n  1, // one\n  // two\n  2, // last\n];\nprint xs;",
+			want:  "var xs = [1, // one\n  // two\n  2 // last\n];\nprint xs;\n",
+		},
+		{
+			name:  "comments inside call",
+			input: "f(a, /* b */ b /* end */);\nm = {\"a\": 1 // a\n};",
+			want:  "f(a, /* b */ b /* end */);\nm = {\"a\": 1 // a\n};\n",
+		},
+		{
+			name:  "comments inside conditions",
+			input: "if (a /* a */) print 1;\nwhile (b // b\n) {}\nfor (;; i = i + 1 /* i */) {}",
+			want:  "if (a /* a */)\n  print 1;\nwhile (b // b\n) {}\nfor (;; i = i + 1 /* i */) {}\n",
+		},
+		{
+			name:  "comment before semicolon",
+			input: "print a /* a */;\nprint b // b\n;",
+			want:  "print a; /* a */\nprint b; // b\n",
+		},
+		{
+			name:  "adjacent minus signs",
+			input: "print - -1;\nprint -(-1);",
+			want:  "print - -1;\nprint -(-1);\n",
+		},
+		{
+			name:  "comment after one-line function",
+			input: "fun f() { return 1; } // f\nprint f();",
+			want:  "fun f() {\n  return 1;\n} // f\nprint f();\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Format("", tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			again, err := Format("", got)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func Test_FormatInvalid(t *testing.T) {
+	_, err := Format("", "var = ;")
+	var diags DiagnosticList
+	require.ErrorAs(t, err, &diags)
+	assert.True(t, diags.HasErrors())
+}
+
+func Test_FormatTestData(t *testing.T) {
+	scripts, err := filepath.Glob("../test_data/*.lox")
+	require.NoError(t, err)
+	require.NotEmpty(t, scripts)
+
+	for _, script := range scripts {
+		t.Run(filepath.Base(script), func(t *testing.T) {
+			src, err := os.ReadFile(script)
+			require.NoError(t, err)
+
+			formatted, err := Format(script, string(src))
+			require.NoError(t, err)
+
+			again, err := Format(script, formatted)
+			require.NoError(t, err)
+			assert.Equal(t, formatted, again)
+
+			var want, got bytes.Buffer
+			_, err = New(WithStdout(&want)).Eval(context.Background(), string(src))
+			require.NoError(t, err)
+			_, err = New(WithStdout(&got)).Eval(context.Background(), formatted)
+			require.NoError(t, err)
+			assert.Equal(t, want.String(), got.String())
+		})
+	}
+}
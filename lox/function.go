@@ -0,0 +1,82 @@
+package lox
+
+import (
+	"fmt"
+)
+
+var (
+	thisToken  = &Token{Type: THIS, Lexeme: "this"}
+	superToken = &Token{Type: SUPER, Lexeme: "super"}
+)
+
+type loxFunction[T any] struct {
+	declaration   *Function
+	closure       *Environment
+	isInitializer bool
+}
+
+func newLoxFunction[T any](declaration *Function, env *Environment, isInitializer bool) *loxFunction[T] {
+	return &loxFunction[T]{
+		declaration:   declaration,
+		closure:       env,
+		isInitializer: isInitializer,
+	}
+}
+
+func (f *loxFunction[T]) call(i *Interpreter[T], paren *Token, args []any) (retVal T) {
+	if limit := i.host.maxCallDepth(); len(i.frames) >= limit {
+		panic(NewNativeError(paren, StackOverflowError{Limit: limit}))
+	}
+	i.frames = append(i.frames, StackFrame{Function: f.declaration.Name.Lexeme, Call: paren})
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			r = i.withStack(r)
+		}
+		i.frames = i.frames[:len(i.frames)-1]
+
+		if r != nil {
+			if v, ok := r.(*ReturnValue); ok {
+				retVal = v.Value.(T)
+			} else {
+				panic(r)
+			}
+		} else {
+			retVal = any(&NilT{}).(T)
+		}
+
+		if f.isInitializer {
+			retVal = f.closure.Get(thisToken).(T)
+		}
+	}()
+
+	i.checkContext()
+
+	env := NewEnvironment(f.closure)
+	for i, p := range f.declaration.Params {
+		env.Define(p, args[i])
+	}
+
+	i.executeBlock(f.declaration.Body, env)
+
+	return retVal
+}
+
+func (f *loxFunction[T]) bind(instance *loxInstance[T]) *loxFunction[T] {
+	env := NewEnvironment(f.closure)
+	env.Define(thisToken, instance)
+	return &loxFunction[T]{
+		declaration:   f.declaration,
+		closure:       env,
+		isInitializer: f.isInitializer,
+	}
+}
+
+func (f *loxFunction[T]) arity() int {
+	return len(f.declaration.Params)
+}
+
+func (f *loxFunction[T]) String() string {
+	return fmt.Sprintf("<fn %s>", f.declaration.Name.Lexeme)
+}
@@ -60,6 +60,11 @@ func main() {
 					},
 					"Literal": map[string]any{
 						"Value": "any",
+						"Token": "*Token",
+					},
+					"Interpolation": map[string]any{
+						"Strings": "[]*Token",
+						"Exprs":   "[]Expr",
 					},
 					"Unary": map[string]any{
 						"Operator": "*Token",
@@ -77,6 +82,46 @@ func main() {
 						"Operator": "*Token",
 						"Right":    "Expr",
 					},
+					"Get": map[string]any{
+						"Object": "Expr",
+						"Name":   "*Token",
+					},
+					"Set": map[string]any{
+						"Object": "Expr",
+						"Name":   "*Token",
+						"Value":  "Expr",
+					},
+					"This": map[string]any{
+						"Keyword": "*Token",
+					},
+					"Super": map[string]any{
+						"Keyword": "*Token",
+						"Method":  "*Token",
+					},
+					"Lambda": map[string]any{
+						"Keyword":  "*Token",
+						"Function": "*Function",
+					},
+					"ListLiteral": map[string]any{
+						"Bracket":  "*Token",
+						"Elements": "[]Expr",
+					},
+					"MapLiteral": map[string]any{
+						"Brace":  "*Token",
+						"Keys":   "[]Expr",
+						"Values": "[]Expr",
+					},
+					"Index": map[string]any{
+						"Object":  "Expr",
+						"Bracket": "*Token",
+						"Index":   "Expr",
+					},
+					"IndexSet": map[string]any{
+						"Object":  "Expr",
+						"Bracket": "*Token",
+						"Index":   "Expr",
+						"Value":   "Expr",
+					},
 				},
 			},
 			"Stmt": {
@@ -85,6 +130,11 @@ func main() {
 					"Expression": map[string]any{
 						"Expression": "Expr",
 					},
+					"Class": map[string]any{
+						"Name":       "*Token",
+						"Superclass": "*Variable",
+						"Methods":    "[]*Function",
+					},
 					"Function": map[string]any{
 						"Name":   "*Token",
 						"Params": "[]*Token",
@@ -112,6 +162,29 @@ func main() {
 					"While": map[string]any{
 						"Condition": "Expr",
 						"Body":      "Stmt",
+						"Increment": "Expr",
+					},
+					"Import": map[string]any{
+						"Keyword": "*Token",
+						"Path":    "*Token",
+						"Name":    "*Token",
+					},
+					"Throw": map[string]any{
+						"Keyword": "*Token",
+						"Value":   "Expr",
+					},
+					"Try": map[string]any{
+						"Keyword":   "*Token",
+						"Body":      "*Block",
+						"CatchName": "*Token",
+						"Catch":     "*Block",
+						"Finally":   "*Block",
+					},
+					"Break": map[string]any{
+						"Keyword": "*Token",
+					},
+					"Continue": map[string]any{
+						"Keyword": "*Token",
 					},
 				},
 			},
@@ -134,7 +207,7 @@ var astTemplate = template.Must(template.New("").Parse(
 	`// Code generated by go generate; DO NOT EDIT.
 // Source: {{ .SourceFileName }}
 
-package main
+package lox
 
 {{ range $class, $ast := .AST }}
 type {{ $class }} interface {}
@@ -0,0 +1,185 @@
+package lox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// host is the state the built-ins of one VM share, whichever backend runs
+// the script.
+type host struct {
+	// ctx is the context of the running script.
+	ctx context.Context
+
+	stdout io.Writer
+	stderr io.Writer
+	stdin  *bufio.Reader
+
+	rand  *rand.Rand
+	clock Clock
+	start time.Time
+
+	limits   Limits
+	steps    int
+	deadline time.Time
+}
+
+func newHost() *host {
+	h := &host{
+		ctx:    context.Background(),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		stdin:  bufio.NewReader(os.Stdin),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	h.setClock(systemClock{})
+	return h
+}
+
+func (h *host) setClock(clock Clock) {
+	h.clock = clock
+	h.start = clock.Now()
+}
+
+// natives returns every built-in, including the ones bound to the host.
+func (h *host) natives() []native {
+	return append(slices.Clone(stdlib),
+		native{name: "readLine", arity: 0, fn: plain(h.readLine)},
+		native{name: "random", arity: 0, fn: plain(h.random)},
+		native{name: "seed", arity: 1, fn: plain(h.seed)},
+		native{name: "clock", arity: 0, fn: plain(h.elapsed)},
+		native{name: "now", arity: 0, fn: plain(h.now)},
+		native{name: "sleep", arity: 1, fn: plain(h.sleep)},
+		native{name: "formatDate", arity: -1, fn: plain(h.formatDate)},
+		native{name: "parseDate", arity: -1, fn: plain(h.parseDate)},
+	)
+}
+
+// elapsed returns the seconds since the VM started, read from the monotonic
+// clock.
+func (h *host) elapsed(args []Value) (Value, error) {
+	return h.clock.Now().Sub(h.start).Seconds(), nil
+}
+
+// now returns the wall clock time as seconds since the Unix epoch.
+func (h *host) now(args []Value) (Value, error) {
+	return unixSeconds(h.clock.Now()), nil
+}
+
+func (h *host) sleep(args []Value) (Value, error) {
+	seconds, err := numberArg("sleep", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if seconds < 0 {
+		return nil, fmt.Errorf("sleep: expected non-negative number, got %v.", seconds)
+	}
+
+	d := time.Duration(seconds * float64(time.Second))
+	if !h.deadline.IsZero() {
+		d = min(d, h.deadline.Sub(h.clock.Now()))
+	}
+
+	select {
+	case <-h.clock.After(d):
+	case <-h.ctx.Done():
+		panic(CancelError{Err: h.ctx.Err()})
+	}
+
+	h.checkDeadline()
+	return nil, nil
+}
+
+func (h *host) formatDate(args []Value) (Value, error) {
+	if err := checkArgs("formatDate", args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	seconds, err := numberArg("formatDate", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := layoutArg("formatDate", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Unix(0, int64(seconds*float64(time.Second))).In(h.clock.Now().Location())
+	return layout.format(t), nil
+}
+
+func (h *host) parseDate(args []Value) (Value, error) {
+	if err := checkArgs("parseDate", args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	s, err := stringArg("parseDate", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := layoutArg("parseDate", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := layout.parse(s, h.clock.Now().Location())
+	if err != nil {
+		return nil, fmt.Errorf("parseDate: cannot parse %q.", s)
+	}
+	return unixSeconds(t), nil
+}
+
+func layoutArg(name string, args []Value, n int) (dateLayout, error) {
+	if len(args) <= n {
+		return parseDateLayout(name, defaultDateLayout)
+	}
+
+	layout, err := stringArg(name, args, n)
+	if err != nil {
+		return nil, err
+	}
+	return parseDateLayout(name, layout)
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// readLine returns the next line of input without its line break, or nil
+// at the end of the input.
+func (h *host) readLine(args []Value) (Value, error) {
+	line, err := h.stdin.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("readLine: %w", err)
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func (h *host) random(args []Value) (Value, error) {
+	return h.rand.Float64(), nil
+}
+
+func (h *host) seed(args []Value) (Value, error) {
+	seed, err := intArg("seed", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	h.rand = rand.New(rand.NewSource(int64(seed)))
+	return nil, nil
+}
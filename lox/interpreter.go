@@ -0,0 +1,649 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type loxCallable[T any] interface {
+	call(i *Interpreter[T], paren *Token, args []any) T
+	arity() int
+}
+
+type ReturnValue struct {
+	Value Expr
+}
+
+// loopSignal unwinds the body of the innermost loop on break and continue.
+type loopSignal int
+
+const (
+	loopBreak loopSignal = iota
+	loopContinue
+)
+
+type Interpreter[T any] struct {
+	globals *Environment
+	env     *Environment
+	locals  map[Expr]int
+	natives map[string]any
+	host    *host
+	frames  []StackFrame
+
+	importModule importFunc
+	// debugHook is called before every statement while a debugger runs the
+	// script.
+	debugHook func(s Stmt)
+}
+
+func NewInterpreter() *Interpreter[any] {
+	return newInterpreter(newHost())
+}
+
+func newInterpreter(h *host) *Interpreter[any] {
+	globals := NewEnvironment(nil)
+	i := &Interpreter[any]{
+		globals: globals,
+		env:     globals,
+		locals:  make(map[Expr]int),
+		natives: make(map[string]any),
+		host:    h,
+	}
+
+	for _, native := range h.natives() {
+		i.defineNative(native.name, native.arity, native.fn)
+	}
+
+	return i
+}
+
+func (i *Interpreter[T]) defineNative(name string, arity int, fn builtinFunc) {
+	native := newNativeFunction[T](name, arity, fn)
+	i.natives[name] = native
+	i.globals.Define(&Token{Lexeme: name}, native)
+}
+
+// runModule executes the statements of an imported file in a fresh global
+// scope for the import statement at token.
+func (i *Interpreter[T]) runModule(token *Token, stmts []Stmt) *Module {
+	globals := NewEnvironment(nil)
+	for name, native := range i.natives {
+		globals.values[name] = native
+	}
+
+	prev := i.env
+	i.env = globals
+	i.frames = append(i.frames, StackFrame{Function: moduleFrame, Call: token})
+	defer func() {
+		i.env = prev
+		i.frames = i.frames[:len(i.frames)-1]
+	}()
+
+	for _, s := range stmts {
+		i.execute(s)
+	}
+
+	return &Module{lookup: func(name string) (Value, bool) {
+		value, ok := globals.values[name]
+		return value, ok
+	}}
+}
+
+func (i *Interpreter[T]) Interpret(statements []Stmt) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var runtimeErr RuntimeError
+			if e, ok := r.(error); ok && errors.As(e, &runtimeErr) {
+				err = runtimeErr
+			} else if haltErr, ok := r.(haltError); ok {
+				err = haltErr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	for n, s := range statements {
+		if expr, ok := s.(*Expression); ok && n == len(statements)-1 {
+			value = i.evaluate(expr.Expression)
+			break
+		}
+		i.execute(s)
+	}
+
+	i.checkContext()
+	return value, nil
+}
+
+// checkContext stops the script with the active calls as its stack.
+func (i *Interpreter[T]) checkContext() {
+	i.host.checkContext(func() []StackFrame { return slices.Clone(i.frames) })
+}
+
+// withStack attaches the active calls to a runtime error or cancellation
+// raised inside them, before the innermost call returns.
+func (i *Interpreter[T]) withStack(r any) any {
+	switch err := r.(type) {
+	case RuntimeError:
+		if err.Stack == nil {
+			err.Stack = slices.Clone(i.frames)
+			return err
+		}
+	case CancelError:
+		if err.Stack == nil {
+			err.Stack = slices.Clone(i.frames)
+			return err
+		}
+	}
+	return r
+}
+
+func (i *Interpreter[T]) resolve(e Expr, depth int) {
+	i.locals[e] = depth
+}
+
+func (i *Interpreter[T]) evaluate(e Expr) T {
+	i.host.step()
+	return AcceptExprVisitor[T](e, i)
+}
+
+func (i *Interpreter[T]) execute(s Stmt) {
+	i.host.step()
+	if i.debugHook != nil {
+		i.debugHook(s)
+	}
+	AcceptStmtVisitor[T](s, i)
+}
+
+func (i *Interpreter[T]) VisitExpressionStmt(s *Expression) {
+	i.evaluate(s.Expression)
+}
+
+func (i *Interpreter[T]) VisitClassStmt(s *Class) {
+	var superclass *loxClass[T]
+	if s.Superclass != nil {
+		var ok bool
+		superclass, ok = any(i.evaluate(s.Superclass)).(*loxClass[T])
+		if !ok {
+			panic(NewRuntimeError(s.Superclass.Name, "Superclass must be a class."))
+		}
+	}
+
+	i.env.Define(s.Name, nil)
+
+	env := i.env
+	if superclass != nil {
+		env = NewEnvironment(i.env)
+		env.Define(superToken, superclass)
+	}
+
+	methods := make(map[string]*loxFunction[T], len(s.Methods))
+	for _, method := range s.Methods {
+		methods[method.Name.Lexeme] = newLoxFunction[T](method, env, method.Name.Lexeme == "init")
+	}
+
+	i.env.Assign(s.Name, newLoxClass(s.Name.Lexeme, superclass, methods))
+}
+
+func (i *Interpreter[T]) VisitFunctionStmt(s *Function) {
+	fn := newLoxFunction[T](s, i.env, false)
+	i.env.Define(s.Name, fn)
+}
+
+func (i *Interpreter[T]) VisitIfStmt(s *If) {
+	if toBool(i.evaluate(s.Expression)) {
+		i.execute(s.ThenBranch)
+	} else {
+		i.execute(s.ElseBranch)
+	}
+}
+
+func (i *Interpreter[T]) VisitPrintStmt(s *Print) {
+	value := i.evaluate(s.Expression)
+	fmt.Fprintln(i.host.stdout, stringify(value))
+}
+
+func (i *Interpreter[T]) VisitReturnStmt(s *Return) {
+	var value Expr = &NilT{}
+	if _, ok := s.Value.(*NilT); !ok {
+		value = i.evaluate(s.Value)
+	}
+	panic(&ReturnValue{Value: value})
+}
+
+func (i *Interpreter[T]) VisitWhileStmt(s *While) {
+	for toBool(i.evaluate(s.Condition)) {
+		if i.executeLoopBody(s.Body) == loopBreak {
+			return
+		}
+		if s.Increment != nil {
+			i.evaluate(s.Increment)
+		}
+		i.checkContext()
+	}
+}
+
+func (i *Interpreter[T]) executeLoopBody(body Stmt) (signal loopSignal) {
+	defer func() {
+		if r := recover(); r != nil {
+			s, ok := r.(loopSignal)
+			if !ok {
+				panic(r)
+			}
+			signal = s
+		}
+	}()
+
+	i.execute(body)
+	return loopContinue
+}
+
+func (i *Interpreter[T]) VisitImportStmt(s *Import) {
+	if i.importModule == nil {
+		panic(NewRuntimeError(s.Keyword, "Imports are not supported."))
+	}
+
+	i.env.Define(s.Name, i.importModule(s.Keyword, s.Path.Literal.(string)))
+}
+
+func (i *Interpreter[T]) VisitThrowStmt(s *Throw) {
+	panic(NewThrowError(s.Keyword, i.evaluate(s.Value)))
+}
+
+func (i *Interpreter[T]) VisitTryStmt(s *Try) {
+	if s.Finally != nil {
+		defer func() {
+			r := recover()
+			if _, ok := r.(haltError); !ok {
+				i.execute(s.Finally)
+			}
+			if r != nil {
+				panic(r)
+			}
+		}()
+	}
+
+	if s.Catch == nil {
+		i.execute(s.Body)
+		return
+	}
+
+	if err, caught := i.executeProtected(s.Body); caught {
+		env := NewEnvironment(i.env)
+		env.Define(s.CatchName, caughtValue(err))
+		i.executeBlock([]Stmt{s.Catch}, env)
+	}
+}
+
+func (i *Interpreter[T]) executeProtected(stmt Stmt) (err RuntimeError, caught bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error)
+			if !ok || !errors.As(e, &err) {
+				panic(r)
+			}
+			caught = true
+		}
+	}()
+
+	i.execute(stmt)
+	return err, false
+}
+
+func (i *Interpreter[T]) VisitBreakStmt(s *Break) {
+	panic(loopBreak)
+}
+
+func (i *Interpreter[T]) VisitContinueStmt(s *Continue) {
+	panic(loopContinue)
+}
+
+func (i *Interpreter[T]) VisitVarStmt(s *Var) {
+	var value interface{}
+	if s.Initializer != nil {
+		value = i.evaluate(s.Initializer)
+	}
+
+	i.env.Define(s.Name, value)
+}
+
+func (i *Interpreter[T]) VisitBlockStmt(s *Block) {
+	i.executeBlock(s.Statements, NewEnvironment(i.env))
+}
+
+func (i *Interpreter[T]) executeBlock(stmts []Stmt, env *Environment) {
+	previousEnv := i.env
+	defer func() {
+		i.env = previousEnv
+	}()
+
+	i.env = env
+	for _, stmt := range stmts {
+		i.execute(stmt)
+	}
+}
+
+func (i *Interpreter[T]) VisitLogicalExpr(e *Logical) T {
+	left := any(i.evaluate(e.Left))
+
+	if e.Operator.Type == OR {
+		if toBool(left) {
+			return left.(T)
+		}
+	} else {
+		if !toBool(left) {
+			return left.(T)
+		}
+	}
+
+	return any(i.evaluate(e.Right)).(T)
+}
+
+func (i *Interpreter[T]) VisitBinaryExpr(e *Binary) T {
+	var v any
+
+	left := any(i.evaluate(e.Left))
+	right := any(i.evaluate(e.Right))
+
+	switch e.Operator.Type {
+	case EQUAL_EQUAL:
+		return any(isEqual(left, right)).(T)
+	case BANG_EQUAL:
+		return any(!isEqual(left, right)).(T)
+	}
+
+	lStr, lok := left.(string)
+	rStr, rok := right.(string)
+	if lok && rok {
+		return i.visitBinaryStringExpr(e.Operator, lStr, rStr)
+	}
+
+	lFl, lok := left.(float64)
+	rFl, rok := right.(float64)
+	if lok && rok {
+		return i.visitBinaryFloat64Expr(e.Operator, lFl, rFl)
+	}
+
+	if !lok || !rok {
+		panic(NewRuntimeError(e.Operator, fmt.Sprintf("Unsupported operands: %v %v", left, right)))
+	}
+
+	return v.(T)
+}
+
+func (i *Interpreter[T]) visitBinaryStringExpr(op *Token, l, r string) T {
+	var v any
+	switch op.Type {
+	case PLUS:
+		v = l + r
+		if err := i.host.checkSize(v); err != nil {
+			panic(NewNativeError(op, err))
+		}
+	case GREATER:
+		v = l > r
+	case GREATER_EQUAL:
+		v = l >= r
+	case LESS:
+		v = l < r
+	case LESS_EQUAL:
+		v = l <= r
+	default:
+		panic(NewRuntimeError(op, fmt.Sprintf("Unsupported operands: %v %v", l, r)))
+	}
+	return v.(T)
+}
+
+func (i *Interpreter[T]) visitBinaryFloat64Expr(op *Token, l, r float64) T {
+	var v any
+	switch op.Type {
+	case MINUS:
+		v = l - r
+	case SLASH:
+		if r == 0 {
+			panic(NewRuntimeError(op, "Division by zero"))
+		}
+		v = l / r
+	case STAR:
+		v = l * r
+	case PLUS:
+		v = l + r
+	case GREATER:
+		v = l > r
+	case GREATER_EQUAL:
+		v = l >= r
+	case LESS:
+		v = l < r
+	case LESS_EQUAL:
+		v = l <= r
+	default:
+		panic(NewRuntimeError(op, fmt.Sprintf("Unsupported operands: %v %v", l, r)))
+	}
+	return v.(T)
+}
+
+func (i *Interpreter[T]) VisitCallExpr(e *Call) T {
+	callee := i.evaluate(e.Callee)
+
+	args := make([]interface{}, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, i.evaluate(arg))
+	}
+
+	return i.call(callee, e.Paren, args)
+}
+
+func (i *Interpreter[T]) call(callee T, paren *Token, args []any) T {
+	f, ok := any(callee).(loxCallable[T])
+	if !ok {
+		panic(NewRuntimeError(paren, "Can only call functions and classes."))
+	}
+
+	if f.arity() >= 0 && f.arity() != len(args) {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Expected %d arguments but got %d.", f.arity(), len(args))))
+	}
+
+	return f.call(i, paren, args)
+}
+
+func (i *Interpreter[T]) VisitGetExpr(e *Get) T {
+	object := i.evaluate(e.Object)
+	if ns, ok := any(object).(namespace); ok {
+		value, ok := ns.get(e.Name.Lexeme)
+		if !ok {
+			panic(NewRuntimeError(e.Name, fmt.Sprintf("Undefined property '%s'.", e.Name.Lexeme)))
+		}
+		return value.(T)
+	}
+
+	instance, ok := any(object).(*loxInstance[T])
+	if !ok {
+		panic(NewRuntimeError(e.Name, "Only instances have properties."))
+	}
+
+	return instance.get(e.Name).(T)
+}
+
+func (i *Interpreter[T]) VisitSetExpr(e *Set) T {
+	object := i.evaluate(e.Object)
+	instance, ok := any(object).(*loxInstance[T])
+	if !ok {
+		panic(NewRuntimeError(e.Name, "Only instances have fields."))
+	}
+
+	value := i.evaluate(e.Value)
+	instance.set(e.Name, value)
+
+	return value
+}
+
+func (i *Interpreter[T]) VisitLambdaExpr(e *Lambda) T {
+	return any(newLoxFunction[T](e.Function, i.env, false)).(T)
+}
+
+func (i *Interpreter[T]) VisitInterpolationExpr(e *Interpolation) T {
+	var b strings.Builder
+	for n, expr := range e.Exprs {
+		b.WriteString(e.Strings[n].Literal.(string))
+		b.WriteString(stringify(i.evaluate(expr)))
+	}
+	b.WriteString(e.Strings[len(e.Strings)-1].Literal.(string))
+
+	s := b.String()
+	if err := i.host.checkSize(s); err != nil {
+		panic(NewNativeError(e.Strings[0], err))
+	}
+
+	return any(s).(T)
+}
+
+func (i *Interpreter[T]) VisitListLiteralExpr(e *ListLiteral) T {
+	values := make([]Value, 0, len(e.Elements))
+	for _, element := range e.Elements {
+		values = append(values, i.evaluate(element))
+	}
+
+	list := NewList(values...)
+	if err := i.host.checkSize(list); err != nil {
+		panic(NewNativeError(e.Bracket, err))
+	}
+
+	return any(list).(T)
+}
+
+func (i *Interpreter[T]) VisitMapLiteralExpr(e *MapLiteral) T {
+	m := NewMap()
+	for n := range e.Keys {
+		key, err := mapKey(i.evaluate(e.Keys[n]))
+		if err != nil {
+			panic(NewRuntimeError(e.Brace, err.Error()))
+		}
+		m.Set(key, i.evaluate(e.Values[n]))
+	}
+
+	if err := i.host.checkSize(m); err != nil {
+		panic(NewNativeError(e.Brace, err))
+	}
+
+	return any(m).(T)
+}
+
+func (i *Interpreter[T]) VisitIndexExpr(e *Index) T {
+	object := i.evaluate(e.Object)
+	index := i.evaluate(e.Index)
+
+	value, err := getIndex(object, index)
+	if err != nil {
+		panic(NewRuntimeError(e.Bracket, err.Error()))
+	}
+
+	return value.(T)
+}
+
+func (i *Interpreter[T]) VisitIndexSetExpr(e *IndexSet) T {
+	object := i.evaluate(e.Object)
+	index := i.evaluate(e.Index)
+	value := i.evaluate(e.Value)
+
+	if err := setIndex(object, index, value); err != nil {
+		panic(NewRuntimeError(e.Bracket, err.Error()))
+	}
+
+	if err := i.host.checkSize(object); err != nil {
+		panic(NewNativeError(e.Bracket, err))
+	}
+
+	return value
+}
+
+func (i *Interpreter[T]) VisitThisExpr(e *This) T {
+	return i.lookUpVariable(e.Keyword, e)
+}
+
+func (i *Interpreter[T]) VisitSuperExpr(e *Super) T {
+	distance := i.locals[e]
+	superclass := i.env.GetAt(distance, e.Keyword).(*loxClass[T])
+	instance := i.env.GetAt(distance-1, thisToken).(*loxInstance[T])
+
+	method := superclass.findMethod(e.Method.Lexeme)
+	if method == nil {
+		panic(NewRuntimeError(e.Method, fmt.Sprintf("Undefined property '%s'.", e.Method.Lexeme)))
+	}
+
+	return any(method.bind(instance)).(T)
+}
+
+func (i *Interpreter[T]) VisitGroupingExpr(e *Grouping) T {
+	return i.evaluate(e.Expression)
+}
+
+func (i *Interpreter[T]) VisitLiteralExpr(e *Literal) T {
+	if e.Value == nil {
+		var v interface{} = "nil"
+		return v.(T)
+	}
+	return e.Value.(T)
+}
+
+func (i *Interpreter[T]) VisitUnaryExpr(e *Unary) T {
+	var v any
+	right := i.evaluate(e.Right)
+	switch e.Operator.Type {
+	case MINUS:
+		if _, ok := any(right).(float64); !ok {
+			panic(NewRuntimeError(e.Operator, fmt.Sprintf("Cannot negate %T", right)))
+		}
+		v = -(any(right).(float64))
+	case BANG:
+		v = any(!toBool(right)).(T)
+	default:
+	}
+	return v.(T)
+}
+
+func (i *Interpreter[T]) VisitVariableExpr(e *Variable) T {
+	return i.lookUpVariable(e.Name, e)
+}
+
+func (i *Interpreter[T]) lookUpVariable(name *Token, e Expr) T {
+	var val any
+	if distance, ok := i.locals[e]; ok {
+		val = i.env.GetAt(distance, name)
+	} else {
+		val = i.env.global().Get(name)
+	}
+
+	if val == nil {
+		panic(NewRuntimeError(name, "Uninitialized variable"))
+	}
+
+	return val.(T)
+}
+
+func (i *Interpreter[T]) VisitAssignExpr(e *Assign) T {
+	value := i.evaluate(e.Value)
+	if distance, ok := i.locals[e]; ok {
+		i.env.AssignAt(distance, e.Name, value)
+	} else {
+		i.env.global().Assign(e.Name, value)
+	}
+
+	return value
+}
+
+func toBool(obj any) bool {
+	if obj == nil {
+		return false
+	}
+
+	if _, ok := obj.(NilT); ok {
+		return false
+	}
+
+	if b, ok := obj.(bool); ok {
+		return b
+	}
+
+	return true
+}
@@ -0,0 +1,253 @@
+package lox
+
+// layout finds what the syntax tree leaves out of a parsed source by walking
+// its tokens along with the statements: the first and last token of every
+// statement and the clauses of for loops, which the parser turns into while
+// loops.
+type layout struct {
+	tokens []*Token
+	index  map[*Token]int
+	spans  map[Stmt]span
+	loops  map[Stmt]*forLoop
+}
+
+type span struct {
+	start, end *Token
+}
+
+type forLoop struct {
+	Initializer Stmt
+	Condition   Expr
+	Increment   Expr
+	Body        Stmt
+}
+
+// newLayout lays out stmts, which must be parsed from tokens without errors.
+func newLayout(tokens []*Token, stmts []Stmt) *layout {
+	l := &layout{
+		tokens: tokens,
+		index:  make(map[*Token]int, len(tokens)),
+		spans:  make(map[Stmt]span),
+		loops:  make(map[Stmt]*forLoop),
+	}
+	for n, t := range tokens {
+		l.index[t] = n
+	}
+
+	l.list(stmts, 0)
+	return l
+}
+
+// list lays out statements written one after the other from the token at n
+// and returns the position of the token after them.
+func (l *layout) list(stmts []Stmt, n int) int {
+	for _, stmt := range stmts {
+		n = l.stmt(stmt, n) + 1
+	}
+	return n
+}
+
+// stmt lays out the statement starting at the token at n and returns the
+// position of its last token.
+func (l *layout) stmt(stmt Stmt, n int) int {
+	end := l.walk(stmt, n)
+	l.spans[stmt] = span{start: l.tokens[n], end: l.tokens[end]}
+	return end
+}
+
+func (l *layout) walk(stmt Stmt, n int) int {
+	if l.tokens[n].Type == FOR {
+		return l.forLoop(stmt, n)
+	}
+
+	switch s := stmt.(type) {
+	case *Block:
+		return l.list(s.Statements, n+1)
+	case *Class:
+		n = l.find(LEFT_BRACE, n)
+		for _, m := range s.Methods {
+			n = l.stmt(m, n+1)
+		}
+		return n + 1
+	case *Function:
+		return l.list(s.Body, l.closing(l.find(LEFT_PAREN, n))+2)
+	case *If:
+		l.expr(s.Expression)
+		end := l.stmt(s.ThenBranch, l.closing(n+1)+1)
+		if s.ElseBranch != nil {
+			end = l.stmt(s.ElseBranch, end+2)
+		}
+		return end
+	case *While:
+		l.expr(s.Condition)
+		return l.stmt(s.Body, l.closing(n+1)+1)
+	case *Try:
+		// try {...} catch (name) {...} finally {...}
+		end := l.stmt(s.Body, n+1)
+		if s.Catch != nil {
+			end = l.stmt(s.Catch, end+5)
+		}
+		if s.Finally != nil {
+			end = l.stmt(s.Finally, end+2)
+		}
+		return end
+	case *Var:
+		l.expr(s.Initializer)
+	case *Print:
+		l.expr(s.Expression)
+	case *Expression:
+		l.expr(s.Expression)
+	case *Return:
+		l.expr(s.Value)
+	case *Throw:
+		l.expr(s.Value)
+	}
+
+	return l.semicolon(n)
+}
+
+// forLoop recovers the clauses of the for loop at the token at n from the
+// while loop the parser made of it.
+func (l *layout) forLoop(stmt Stmt, n int) int {
+	loop := &forLoop{}
+	w, ok := stmt.(*While)
+	if !ok {
+		block := stmt.(*Block)
+		loop.Initializer = block.Statements[0]
+		w = block.Statements[1].(*While)
+	}
+	loop.Increment, loop.Body = w.Increment, w.Body
+
+	// The initializer is laid out but not recorded, as it is printed and run
+	// as part of the loop.
+	clause := n + 2
+	if loop.Initializer != nil {
+		clause = l.walk(loop.Initializer, clause)
+	}
+	if l.tokens[clause+1].Type != SEMICOLON {
+		loop.Condition = w.Condition
+		l.expr(w.Condition)
+	}
+	l.expr(w.Increment)
+
+	l.loops[stmt] = loop
+	return l.stmt(w.Body, l.closing(n+1)+1)
+}
+
+// expr lays out the bodies of the function expressions within expr.
+func (l *layout) expr(expr Expr) {
+	switch e := expr.(type) {
+	case *Assign:
+		l.expr(e.Value)
+	case *Binary:
+		l.expr(e.Left)
+		l.expr(e.Right)
+	case *Call:
+		l.expr(e.Callee)
+		l.exprs(e.Args)
+	case *Get:
+		l.expr(e.Object)
+	case *Grouping:
+		l.expr(e.Expression)
+	case *Index:
+		l.expr(e.Object)
+		l.expr(e.Index)
+	case *IndexSet:
+		l.expr(e.Object)
+		l.expr(e.Index)
+		l.expr(e.Value)
+	case *Interpolation:
+		l.exprs(e.Exprs)
+	case *Lambda:
+		l.lambda(e)
+	case *ListLiteral:
+		l.exprs(e.Elements)
+	case *Logical:
+		l.expr(e.Left)
+		l.expr(e.Right)
+	case *MapLiteral:
+		l.exprs(e.Keys)
+		l.exprs(e.Values)
+	case *Set:
+		l.expr(e.Object)
+		l.expr(e.Value)
+	case *Unary:
+		l.expr(e.Right)
+	}
+}
+
+func (l *layout) exprs(exprs []Expr) {
+	for _, expr := range exprs {
+		l.expr(expr)
+	}
+}
+
+func (l *layout) lambda(e *Lambda) {
+	fn := e.Function
+	brace := l.index[e.Keyword] + 1
+	if e.Keyword.Type == FUN {
+		brace = l.closing(brace) + 1
+	} else if l.tokens[brace].Type != LEFT_BRACE {
+		l.expr(fn.Body[0].(*Return).Value)
+		return
+	}
+
+	end := l.list(fn.Body, brace+1)
+	l.spans[fn] = span{start: e.Keyword, end: l.tokens[end]}
+}
+
+// paren returns the parenthesis that closes the clauses of the if, while or
+// for statement stmt.
+func (l *layout) paren(stmt Stmt) *Token {
+	return l.tokens[l.closing(l.index[l.spans[stmt].start]+1)]
+}
+
+// closer returns the bracket that closes the one at t.
+func (l *layout) closer(t *Token) *Token {
+	return l.tokens[l.closing(l.index[t])]
+}
+
+// next returns the token after t.
+func (l *layout) next(t *Token) *Token {
+	return l.tokens[l.index[t]+1]
+}
+
+// closing returns the position of the bracket that closes the one at n.
+func (l *layout) closing(n int) int {
+	depth := 0
+	for ; ; n++ {
+		switch l.tokens[n].Type {
+		case LEFT_PAREN, LEFT_BRACKET, LEFT_BRACE:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE:
+			if depth--; depth == 0 {
+				return n
+			}
+		}
+	}
+}
+
+// semicolon returns the position of the semicolon that ends the statement
+// starting at n.
+func (l *layout) semicolon(n int) int {
+	depth := 0
+	for ; ; n++ {
+		switch l.tokens[n].Type {
+		case LEFT_PAREN, LEFT_BRACKET, LEFT_BRACE:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE:
+			depth--
+		case SEMICOLON:
+			if depth == 0 {
+				return n
+			}
+		}
+	}
+}
+
+func (l *layout) find(t TokenType, n int) int {
+	for l.tokens[n].Type != t {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultMaxCallDepth keeps deep but finite recursion working while stopping
+// unbounded recursion well before the tree-walker runs out of Go stack.
+const defaultMaxCallDepth = 20_000
+
+// deadlineInterval is how many steps run between two reads of the clock.
+const deadlineInterval = 256
+
+// Limits bounds the resources one run of a script may use. Zero fields are
+// unlimited, except MaxCallDepth, which defaults to 20,000 nested calls.
+type Limits struct {
+	// MaxSteps bounds the statements and expressions the tree-walker
+	// evaluates, or the instructions the VM executes.
+	MaxSteps          int
+	MaxCallDepth      int
+	MaxStringLength   int
+	MaxCollectionSize int
+	// Timeout bounds the time a run takes, as measured by the clock of the
+	// VM.
+	Timeout time.Duration
+}
+
+// WithLimits bounds what every Eval and RunFile may use, for running
+// untrusted scripts.
+func WithLimits(limits Limits) Option {
+	return func(vm *VM) {
+		vm.host.limits = limits
+	}
+}
+
+// haltError stops a script outright: try/catch and finally blocks do not
+// run for it.
+type haltError interface {
+	error
+	halt()
+}
+
+func (ExitError) halt() {}
+
+// StepLimitError stops a script that ran more steps than Limits.MaxSteps.
+type StepLimitError struct {
+	Limit int
+}
+
+func (e StepLimitError) Error() string {
+	return fmt.Sprintf("step limit of %d exceeded", e.Limit)
+}
+
+func (StepLimitError) halt() {}
+
+// DeadlineError stops a script that ran longer than Limits.Timeout.
+type DeadlineError struct {
+	Timeout time.Duration
+}
+
+func (e DeadlineError) Error() string {
+	return fmt.Sprintf("timeout of %s exceeded", e.Timeout)
+}
+
+func (DeadlineError) halt() {}
+
+// StackOverflowError is the cause of the runtime error raised by a call
+// nested deeper than Limits.MaxCallDepth.
+type StackOverflowError struct {
+	Limit int
+}
+
+func (e StackOverflowError) Error() string {
+	return "Stack overflow."
+}
+
+// StringLengthError is the cause of the runtime error raised when a script
+// builds a string longer than Limits.MaxStringLength.
+type StringLengthError struct {
+	Limit  int
+	Length int
+}
+
+func (e StringLengthError) Error() string {
+	return fmt.Sprintf("String of %d characters exceeds the limit of %d.", e.Length, e.Limit)
+}
+
+// CollectionSizeError is the cause of the runtime error raised when a list
+// or map grows past Limits.MaxCollectionSize.
+type CollectionSizeError struct {
+	Limit int
+	Size  int
+}
+
+func (e CollectionSizeError) Error() string {
+	return fmt.Sprintf("Collection of %d elements exceeds the limit of %d.", e.Size, e.Limit)
+}
+
+// begin resets the step count and deadline for a new run under ctx.
+func (h *host) begin(ctx context.Context) {
+	h.ctx = ctx
+	h.steps = 0
+	h.deadline = time.Time{}
+	if h.limits.Timeout > 0 {
+		h.deadline = h.clock.Now().Add(h.limits.Timeout)
+	}
+}
+
+// step counts one step of the running script and stops it once it is over
+// the step limit or the deadline.
+func (h *host) step() {
+	h.steps++
+	if h.limits.MaxSteps > 0 && h.steps > h.limits.MaxSteps {
+		panic(StepLimitError{Limit: h.limits.MaxSteps})
+	}
+
+	if h.steps%deadlineInterval == 0 {
+		h.checkDeadline()
+	}
+}
+
+// checkContext stops the script once its context is cancelled, with the
+// stack the backend reports. The backends check once more when the script
+// ends, as the context may be cancelled after the last check, by a native
+// for instance, which still fails the run.
+func (h *host) checkContext(stack func() []StackFrame) {
+	select {
+	case <-h.ctx.Done():
+		panic(CancelError{Err: h.ctx.Err(), Stack: stack()})
+	default:
+	}
+}
+
+func (h *host) checkDeadline() {
+	if !h.deadline.IsZero() && !h.clock.Now().Before(h.deadline) {
+		panic(DeadlineError{Timeout: h.limits.Timeout})
+	}
+}
+
+func (h *host) maxCallDepth() int {
+	if h.limits.MaxCallDepth > 0 {
+		return h.limits.MaxCallDepth
+	}
+	return defaultMaxCallDepth
+}
+
+// checkSize reports the first of values that is longer than the limits
+// allow.
+func (h *host) checkSize(values ...Value) error {
+	for _, value := range values {
+		size := -1
+		switch v := value.(type) {
+		case string:
+			limit := h.limits.MaxStringLength
+			if limit > 0 && len(v) > limit {
+				if n := utf8.RuneCountInString(v); n > limit {
+					return StringLengthError{Limit: limit, Length: n}
+				}
+			}
+		case *List:
+			size = v.Len()
+		case *Map:
+			size = v.Len()
+		}
+
+		if limit := h.limits.MaxCollectionSize; limit > 0 && size > limit {
+			return CollectionSizeError{Limit: limit, Size: size}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limits(t *testing.T) {
+	testCases := []struct {
+		name    string
+		limits  Limits
+		input   string
+		want    Value
+		wantOut string
+		wantErr any
+	}{
+		{
+			name:   "within limits",
+			limits: Limits{MaxSteps: 1000, MaxCallDepth: 10, MaxStringLength: 3, MaxCollectionSize: 3, Timeout: time.Minute},
+			input:  `fun f(n) { if (n > 0) return f(n - 1); return [1, 2, 3]; } len(f(5)) + len("ab" + "c");`,
+			want:   6.0,
+		},
+		{
+			name:    "infinite loop",
+			limits:  Limits{MaxSteps: 1000},
+			input:   "while (true) {}",
+			wantErr: &StepLimitError{},
+		},
+		{
+			name:    "step limit is not caught",
+			limits:  Limits{MaxSteps: 1000},
+			input:   `try { while (true) {} } catch (e) { print "caught"; } finally { print "finally"; }`,
+			wantErr: &StepLimitError{},
+		},
+		{
+			name:    "unbounded recursion",
+			input:   "fun f() { f(); } f();",
+			wantErr: &StackOverflowError{},
+		},
+		{
+			name:  "deep recursion",
+			input: "fun sum(n) { if (n == 0) return 0; return n + sum(n - 1); } sum(1000);",
+			want:  500500.0,
+		},
+		{
+			name:   "call depth at the limit",
+			limits: Limits{MaxCallDepth: 10},
+			input:  "fun f(n) { if (n > 0) return f(n - 1) + 1; return 1; } f(9);",
+			want:   10.0,
+		},
+		{
+			name:    "call depth",
+			limits:  Limits{MaxCallDepth: 10},
+			input:   "fun f(n) { if (n > 0) f(n - 1); } f(20);",
+			wantErr: &StackOverflowError{},
+		},
+		{
+			name:    "stack overflow is a runtime error",
+			limits:  Limits{MaxCallDepth: 10},
+			input:   "fun f() { f(); } f();",
+			wantErr: &RuntimeError{},
+		},
+		{
+			name:    "string concatenation",
+			limits:  Limits{MaxStringLength: 8},
+			input:   `var s = "ab"; while (true) s = s + s;`,
+			wantErr: &StringLengthError{},
+		},
+		{
+			name:    "string interpolation",
+			limits:  Limits{MaxStringLength: 8},
+			input:   `var s = "abcde"; "${s}${s}";`,
+			wantErr: &StringLengthError{},
+		},
+		{
+			name:    "native result",
+			limits:  Limits{MaxStringLength: 8},
+			input:   `join([1, 2, 3, 4, 5], ", ");`,
+			wantErr: &StringLengthError{},
+		},
+		{
+			name:    "list literal",
+			limits:  Limits{MaxCollectionSize: 2},
+			input:   "[1, 2, 3];",
+			wantErr: &CollectionSizeError{},
+		},
+		{
+			name:    "push",
+			limits:  Limits{MaxCollectionSize: 100},
+			input:   "var l = []; while (true) push(l, 1);",
+			wantErr: &CollectionSizeError{},
+		},
+		{
+			name:    "map index",
+			limits:  Limits{MaxCollectionSize: 2},
+			input:   `var m = {"a": 1, "b": 2}; m["b"] = 3; m["c"] = 4;`,
+			wantErr: &CollectionSizeError{},
+		},
+		{
+			name:    "deadline",
+			limits:  Limits{Timeout: 10 * time.Second},
+			input:   "while (true) sleep(1);",
+			wantErr: &DeadlineError{},
+		},
+		{
+			name:    "long sleep",
+			limits:  Limits{Timeout: 10 * time.Second},
+			input:   "sleep(3600);",
+			wantErr: &DeadlineError{},
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				clock := &fakeClock{now: time.Unix(1700000000, 0)}
+				var out bytes.Buffer
+				vm := New(WithBackend(backend), WithClock(clock), WithLimits(tc.limits), WithStdout(&out))
+
+				got, err := vm.Eval(context.Background(), tc.input)
+				assert.Equal(t, tc.wantOut, out.String())
+				if tc.wantErr != nil {
+					require.ErrorAs(t, err, tc.wantErr)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			})
+		}
+	}
+}
+
+func Test_LimitsPerRun(t *testing.T) {
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			vm := New(WithBackend(backend), WithLimits(Limits{MaxSteps: 500, Timeout: time.Minute}))
+
+			for n := 0; n < 5; n++ {
+				_, err := vm.Eval(context.Background(), "for (var i = 0; i < 20; i = i + 1) {}")
+				require.NoError(t, err)
+			}
+
+			_, err := vm.Eval(context.Background(), "while (true) {}")
+			require.ErrorAs(t, err, &StepLimitError{})
+
+			got, err := vm.Eval(context.Background(), "1 + 2;")
+			require.NoError(t, err)
+			assert.Equal(t, 3.0, got)
+		})
+	}
+}
+
+func Test_WallClockTimeout(t *testing.T) {
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			_, err := New(WithBackend(backend), WithLimits(Limits{Timeout: 20 * time.Millisecond})).
+				Eval(context.Background(), "while (true) {}")
+
+			var deadlineErr DeadlineError
+			require.ErrorAs(t, err, &deadlineErr)
+			assert.Equal(t, 20*time.Millisecond, deadlineErr.Timeout)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package lox
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type Backend string
+
+const (
+	BackendTreeWalker Backend = "tree"
+	BackendVM         Backend = "vm"
+)
+
+type Option func(*VM)
+
+func WithBackend(backend Backend) Option {
+	return func(vm *VM) {
+		vm.backend = backend
+	}
+}
+
+// WithStdout sets where print writes program output.
+func WithStdout(w io.Writer) Option {
+	return func(vm *VM) {
+		vm.host.stdout = w
+	}
+}
+
+// WithStderr sets where ReportError and RunPrompt write diagnostics.
+func WithStderr(w io.Writer) Option {
+	return func(vm *VM) {
+		vm.host.stderr = w
+	}
+}
+
+// WithStdin sets the input readLine and RunPrompt read from.
+func WithStdin(r io.Reader) Option {
+	return func(vm *VM) {
+		vm.host.stdin = bufio.NewReader(r)
+	}
+}
+
+// WithErrorFormat sets the format ReportError writes diagnostics in.
+func WithErrorFormat(format ErrorFormat) Option {
+	return func(vm *VM) {
+		vm.errorFormat = format
+	}
+}
+
+// WithSearchPath adds directories to look up imported modules in when they
+// are not found relative to the importing file.
+func WithSearchPath(dirs ...string) Option {
+	return func(vm *VM) {
+		vm.modules.searchPath = append(vm.modules.searchPath, dirs...)
+	}
+}
+
+type VM struct {
+	backend     Backend
+	interpreter *Interpreter[any]
+	machine     *stackVM
+	modules     *moduleLoader
+	host        *host
+	errorFormat ErrorFormat
+}
+
+func New(opts ...Option) *VM {
+	h := newHost()
+	vm := &VM{
+		backend:     BackendTreeWalker,
+		interpreter: newInterpreter(h),
+		machine:     newStackVM(h),
+		modules:     newModuleLoader(),
+		host:        h,
+		errorFormat: ErrorFormatHuman,
+	}
+	vm.interpreter.importModule = vm.importModule
+	vm.machine.importModule = vm.importModule
+
+	for _, opt := range opts {
+		opt(vm)
+	}
+
+	return vm
+}
+
+func (vm *VM) RunFile(ctx context.Context, path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file failed: %w", err)
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		vm.modules.loading = append(vm.modules.loading, abs)
+		defer func() {
+			vm.modules.loading = vm.modules.loading[:len(vm.modules.loading)-1]
+		}()
+	}
+
+	_, err = vm.eval(ctx, path, string(src))
+	return err
+}
+
+func (vm *VM) Eval(ctx context.Context, src string) (Value, error) {
+	return vm.eval(ctx, "", src)
+}
+
+func (vm *VM) eval(ctx context.Context, file, src string) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, CancelError{Err: err}
+	}
+
+	stmts, fn, err := vm.prepare(file, src)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.host.begin(ctx)
+	if fn != nil {
+		return vm.machine.Interpret(fn)
+	}
+
+	return vm.interpreter.Interpret(stmts)
+}
+
+// prepare runs the static passes over src. The compiled script is nil for the
+// tree-walking backend.
+func (vm *VM) prepare(file, src string) ([]Stmt, *vmFunction, error) {
+	stmts, diags := Parse(file, src)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	r := newResolver(vm.interpreter)
+	if errs := r.Resolve(stmts); len(errs) > 0 {
+		return nil, nil, newDiagnostics(errs)
+	}
+
+	if vm.backend != BackendVM {
+		return stmts, nil, nil
+	}
+
+	c := newCompiler()
+	fn, errs := c.Compile(stmts)
+	if len(errs) > 0 {
+		return nil, nil, newDiagnostics(errs)
+	}
+
+	return stmts, fn, nil
+}
+
+// RunPrompt evaluates the input line by line, printing the value of every
+// expression and reporting errors, until the input ends or the script exits.
+func (vm *VM) RunPrompt(ctx context.Context) error {
+	for {
+		fmt.Fprint(vm.host.stdout, "> ")
+
+		line, err := vm.host.stdin.ReadString('\n')
+		if line == "" && errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("could not read input: %w", err)
+		}
+
+		value, err := vm.Eval(ctx, strings.TrimRight(line, "\r\n"))
+		var exitErr ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			return err
+		case err != nil:
+			vm.ReportError(err)
+		case value != nil:
+			fmt.Fprintf(vm.host.stdout, "%v\n", value)
+		}
+	}
+}
+
+// ReportError writes the diagnostics of err to the configured error output.
+func (vm *VM) ReportError(err error) {
+	WriteErrors(vm.host.stderr, vm.errorFormat, err)
+}
+
+func Parse(file, src string) ([]Stmt, DiagnosticList) {
+	_, stmts, diags := parse(file, src)
+	return stmts, diags
+}
+
+// parse scans and parses src and reports the diagnostics of both in source
+// order.
+func parse(file, src string) ([]*Token, []Stmt, DiagnosticList) {
+	tokens, diags := newScanner(file, src).Scan()
+	stmts, parseDiags := newParser(tokens).Parse()
+
+	diags = append(diags, parseDiags...)
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		if a.Line != b.Line {
+			return cmp.Compare(a.Line, b.Line)
+		}
+		return cmp.Compare(a.Column, b.Column)
+	})
+
+	return tokens, stmts, diags
+}
+
+func (vm *VM) RegisterNative(name string, arity int, fn NativeFunc) {
+	vm.interpreter.defineNative(name, arity, plain(fn))
+	vm.machine.defineNative(name, arity, plain(fn))
+}
+
+func (vm *VM) RegisterFunc(name string, fn any) error {
+	native, arity, err := wrapFunc(fn)
+	if err != nil {
+		return fmt.Errorf("could not register %s: %w", name, err)
+	}
+
+	vm.RegisterNative(name, arity, native)
+	return nil
+}
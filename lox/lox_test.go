@@ -0,0 +1,370 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Eval(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr any
+		wantMsg string
+	}{
+		{
+			name:  "expression value",
+			input: "var a = 1; a + 2;",
+			want:  3.0,
+		},
+		{
+			name:  "statement has no value",
+			input: "var a = 1;",
+			want:  nil,
+		},
+		{
+			name:  "closure",
+			input: "fun add(a) { fun f(b) { return a + b; } return f; } add(1)(2);",
+			want:  3.0,
+		},
+		{
+			name:  "anonymous function",
+			input: "var add = fun (a, b) { return a + b; }; add(1, 2);",
+			want:  3.0,
+		},
+		{
+			name:  "arrow function closure",
+			input: "fun adder(n) { return (a) => a + n; } adder(1)(2) + (x => x)(3);",
+			want:  6.0,
+		},
+		{
+			name:  "arrow function with block body",
+			input: "var f = (a, b) => { if (a > b) return a; return b; }; f(1, 2);",
+			want:  2.0,
+		},
+		{
+			name:  "immediately invoked function",
+			input: "fun () { return 1; }();",
+			want:  1.0,
+		},
+		{
+			name:  "break",
+			input: "var i = 0; while (true) { i = i + 1; if (i == 3) break; } i;",
+			want:  3.0,
+		},
+		{
+			name:  "continue runs for increment",
+			input: "var s = 0; for (var i = 0; i < 5; i = i + 1) { if (i == 2) continue; s = s + i; } s;",
+			want:  8.0,
+		},
+		{
+			name:  "nil equals nil",
+			input: "nil == nil;",
+			want:  true,
+		},
+		{
+			name:  "booleans are equal",
+			input: "true == true and false != true;",
+			want:  true,
+		},
+		{
+			name:  "values of different types are not equal",
+			input: `1 == "1" or nil == false or 0 == nil;`,
+			want:  false,
+		},
+		{
+			name:  "function result nil",
+			input: "fun f() {} f() == nil;",
+			want:  true,
+		},
+		{
+			name:  "objects are equal to themselves",
+			input: "var l = [1]; l == l and l != [1];",
+			want:  true,
+		},
+		{
+			name:  "init returns this",
+			input: "class A { init(n) { this.n = n; } } var a = A(1); a.init(2) == a and a.n == 2;",
+			want:  true,
+		},
+		{
+			name:  "bound method keeps this",
+			input: `class A { init() { this.name = "a"; } get() { return this.name; } } var m = A().get; m();`,
+			want:  "a",
+		},
+		{
+			name:  "field shadows method",
+			input: `class A { m() { return "method"; } } var a = A(); a.m = fun () { return "field"; }; a.m();`,
+			want:  "field",
+		},
+		{
+			name: "super through three levels",
+			input: `class A { m() { return "A"; } }
+class B < A { m() { return "B" + super.m(); } }
+class C < B { m() { return "C" + super.m(); } }
+C().m();`,
+			want: "CBA",
+		},
+		{
+			name:    "super outside of a class",
+			input:   "super.m();",
+			wantErr: &ResolveError{},
+			wantMsg: "Can't use 'super' outside of a class.",
+		},
+		{
+			name:    "property of non-instance",
+			input:   "var a = 1; a.b;",
+			wantErr: &RuntimeError{},
+			wantMsg: "Only instances have properties.",
+		},
+		{
+			name:    "parse error",
+			input:   "var = 1;",
+			wantErr: &ParseError{},
+		},
+		{
+			name:    "resolve error",
+			input:   "return 1;",
+			wantErr: &ResolveError{},
+		},
+		{
+			name:    "runtime error",
+			input:   "1 / 0;",
+			wantErr: &RuntimeError{},
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				got, err := New(WithBackend(backend)).Eval(context.Background(), tc.input)
+				if tc.wantErr != nil {
+					assert.True(t, errors.As(err, tc.wantErr), "unexpected error: %v", err)
+					if tc.wantMsg != "" {
+						assert.ErrorContains(t, err, tc.wantMsg)
+					}
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			})
+		}
+	}
+}
+
+func Test_EvalIndependentInstances(t *testing.T) {
+	ctx := context.Background()
+	first, second := New(), New()
+
+	_, err := first.Eval(ctx, "var a = 1;")
+	require.NoError(t, err)
+
+	_, err = second.Eval(ctx, "a;")
+	assert.Error(t, err)
+
+	got, err := first.Eval(ctx, "a;")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, got)
+}
+
+func parseSource(t *testing.T, src string) []Stmt {
+	t.Helper()
+
+	tokens, errs := newScanner("", src).Scan()
+	require.Empty(t, errs)
+
+	stmts, errs := newParser(tokens).Parse()
+	require.Empty(t, errs)
+
+	return stmts
+}
+
+func Test_AnonymousFunctionString(t *testing.T) {
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			got, err := New(WithBackend(backend)).Eval(context.Background(), "var f = fun () {};\nvar g = (a) => a;\n[f, g];")
+			require.NoError(t, err)
+			assert.Equal(t, "[<fn anonymous@1>, <fn anonymous@2>]", fmt.Sprint(got))
+		})
+	}
+}
+
+func Test_Streams(t *testing.T) {
+	src := `var line = readLine();
+while (type(line) == "string") {
+  print upper(line);
+  line = readLine();
+}
+print readLine();`
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			vm := New(WithBackend(backend), WithStdout(&out), WithStdin(strings.NewReader("one\r\ntwo\nthree")))
+
+			_, err := vm.Eval(context.Background(), src)
+			require.NoError(t, err)
+			assert.Equal(t, "ONE\nTWO\nTHREE\nnil\n", out.String())
+		})
+	}
+}
+
+func Test_RunPrompt(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		wantOut    string
+		wantErrOut string
+		wantErr    error
+	}{
+		{
+			name:    "values",
+			input:   "var a = 1;\na + 1;\nprint a;",
+			wantOut: "> > 2\n> 1\n> ",
+		},
+		{
+			name:       "errors",
+			input:      "a;\n1;\n",
+			wantOut:    "> > 1\n> ",
+			wantErrOut: "<input>:1:1: runtime error: Undefined variable\n",
+		},
+		{
+			name:    "exit",
+			input:   "exit(2);\nprint 1;\n",
+			wantOut: "> ",
+			wantErr: ExitError{Code: 2},
+		},
+		{
+			name:    "read line",
+			input:   "print readLine();\nhello\n",
+			wantOut: "> hello\n> ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			vm := New(
+				WithStdin(strings.NewReader(tc.input)),
+				WithStdout(&out),
+				WithStderr(&errOut),
+				WithErrorFormat(ErrorFormatPlain),
+			)
+
+			err := vm.RunPrompt(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantOut, out.String())
+			assert.Equal(t, tc.wantErrOut, errOut.String())
+		})
+	}
+}
+
+func Test_Cancel(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		wantStack []string
+	}{
+		{
+			name: "loop",
+			input: `fun inner() { cancel(); while (true) {} }
+fun outer() {
+  try { inner(); } catch (e) {} finally { print "finally"; }
+}
+outer();`,
+			wantStack: []string{"outer:5", "inner:3"},
+		},
+		{
+			name:      "function entry",
+			input:     "fun f() { print \"called\"; }\ncancel();\nf();",
+			wantStack: []string{"f:3"},
+		},
+		{
+			name: "callback",
+			input: `fun spin() { while (true) {} }
+map([1], (x) => {
+  cancel();
+  spin();
+});`,
+			wantStack: []string{"map:5", "anonymous@2:5", "spin:4"},
+		},
+		{
+			name:      "sleep",
+			input:     "fun nap() { cancel(); sleep(3600); }\nnap();",
+			wantStack: []string{"nap:2", "sleep:1"},
+		},
+		{
+			name:      "last statement",
+			input:     "cancel();",
+			wantStack: []string{},
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				var out bytes.Buffer
+				vm := New(WithBackend(backend), WithStdout(&out))
+				vm.RegisterNative("cancel", 0, func(args []Value) (Value, error) {
+					cancel()
+					return nil, nil
+				})
+
+				_, err := vm.Eval(ctx, tc.input)
+				require.ErrorIs(t, err, context.Canceled)
+
+				var cancelErr CancelError
+				require.ErrorAs(t, err, &cancelErr)
+				assert.Empty(t, out.String())
+
+				stack := make([]string, 0, len(cancelErr.Stack))
+				for _, frame := range cancelErr.Stack {
+					stack = append(stack, fmt.Sprintf("%s:%d", frame.Function, frame.Call.Line))
+				}
+				assert.Equal(t, tc.wantStack, stack)
+			})
+		}
+	}
+
+	t.Run("cancelled before running", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := New().Eval(ctx, "1;")
+		require.ErrorAs(t, err, &CancelError{})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("during sleep", func(t *testing.T) {
+		for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := New(WithBackend(backend)).Eval(ctx, "sleep(2); 1;")
+			require.ErrorIs(t, err, context.DeadlineExceeded, backend)
+			assert.Less(t, time.Since(start), time.Second, backend)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := New(WithBackend(BackendVM)).Eval(ctx, "while (true) {}")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,167 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Module is the namespace an import statement binds. It exposes the
+// top-level names of the imported file, except names starting with an
+// underscore.
+type Module struct {
+	Path    string
+	exports map[string]bool
+	lookup  func(name string) (Value, bool)
+}
+
+func (m *Module) get(name string) (Value, bool) {
+	if !m.exports[name] {
+		return nil, false
+	}
+
+	return m.lookup(name)
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.Path)
+}
+
+// namespace is a value with read-only properties.
+type namespace interface {
+	get(name string) (Value, bool)
+}
+
+// moduleFrame names the top level of an imported file in stack traces.
+const moduleFrame = "<module>"
+
+// A static error in an imported file stops the script like one in the
+// script itself.
+func (DiagnosticList) halt() {}
+
+// importFunc loads the module at path for the import statement at token.
+type importFunc func(token *Token, path string) *Module
+
+func exportedNames(stmts []Stmt) map[string]bool {
+	names := make(map[string]bool)
+	for _, stmt := range stmts {
+		var name *Token
+		switch s := stmt.(type) {
+		case *Var:
+			name = s.Name
+		case *Function:
+			name = s.Name
+		case *Class:
+			name = s.Name
+		case *Import:
+			name = s.Name
+		default:
+			continue
+		}
+
+		if !strings.HasPrefix(name.Lexeme, "_") {
+			names[name.Lexeme] = true
+		}
+	}
+	return names
+}
+
+type moduleLoader struct {
+	searchPath []string
+	modules    map[string]*Module
+	loading    []string
+}
+
+func newModuleLoader() *moduleLoader {
+	return &moduleLoader{
+		modules: make(map[string]*Module),
+	}
+}
+
+// find resolves path relative to the directory of the importing file and
+// then to each directory of the search path.
+func (l *moduleLoader) find(token *Token, path string) (string, error) {
+	var candidates []string
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		dir := "."
+		if token.Source != nil && token.Source.File != "" {
+			dir = filepath.Dir(token.Source.File)
+		}
+		candidates = append(candidates, filepath.Join(dir, path))
+		for _, dir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Abs(candidate)
+	}
+
+	return "", fmt.Errorf("Module %q not found.", path)
+}
+
+// cycle returns the import chain that leads back to path, if path is still
+// being loaded.
+func (l *moduleLoader) cycle(path string) []string {
+	for n, loading := range l.loading {
+		if loading == path {
+			return append(append([]string(nil), l.loading[n:]...), path)
+		}
+	}
+	return nil
+}
+
+func (vm *VM) importModule(token *Token, path string) *Module {
+	resolved, err := vm.modules.find(token, path)
+	if err != nil {
+		panic(NewRuntimeError(token, err.Error()))
+	}
+
+	if module, ok := vm.modules.modules[resolved]; ok {
+		return module
+	}
+
+	if chain := vm.modules.cycle(resolved); chain != nil {
+		panic(NewRuntimeError(token, fmt.Sprintf("Import cycle: %s.", strings.Join(chain, " -> "))))
+	}
+
+	src, err := os.ReadFile(resolved)
+	if err != nil {
+		panic(NewNativeError(token, err))
+	}
+
+	vm.modules.loading = append(vm.modules.loading, resolved)
+	defer func() {
+		vm.modules.loading = vm.modules.loading[:len(vm.modules.loading)-1]
+	}()
+
+	stmts, fn, err := vm.prepare(resolved, string(src))
+	if err != nil {
+		panic(err)
+	}
+
+	var module *Module
+	if fn != nil {
+		module = vm.machine.runModule(fn)
+	} else {
+		module = vm.interpreter.runModule(token, stmts)
+	}
+	module.Path = resolved
+	module.exports = exportedNames(stmts)
+
+	vm.modules.modules[resolved] = module
+	return module
+}
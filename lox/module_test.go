@@ -0,0 +1,137 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	}
+	return dir
+}
+
+func Test_Import(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.lox": `import "lib/counter.lox" as c;
+import "lib/counter.lox" as again;
+var count = 100;
+print c.inc();
+print again.inc();
+print c.count;
+print count;
+print c.greeter.greet("lox");
+print c.Box(3).value;`,
+		"lib/counter.lox": `import "greeter.lox" as greeter;
+print "loading counter";
+var count = 0;
+var _step = 1;
+fun inc() { count = count + _step; return count; }
+class Box { init(value) { this.value = value; } }`,
+		"shared/greeter.lox": `fun greet(name) { return "hello " + name; }`,
+	})
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			vm := New(WithBackend(backend), WithSearchPath(filepath.Join(dir, "shared")), WithStdout(&out))
+
+			err := vm.RunFile(context.Background(), filepath.Join(dir, "main.lox"))
+
+			require.NoError(t, err)
+			assert.Equal(t, "loading counter\n1\n2\n2\n100\nhello lox\n3\n", out.String())
+		})
+	}
+}
+
+func Test_ImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"private.lox": `import "a.lox" as a; a._hidden;`,
+		"a.lox":       `var _hidden = 1;`,
+		"missing.lox": `import "nope.lox" as nope;`,
+		"cycle.lox":   `import "b.lox" as b;`,
+		"b.lox":       `import "c.lox" as c;`,
+		"c.lox":       `import "b.lox" as b;`,
+		"broken.lox":  `import "syntax.lox" as s;`,
+		"syntax.lox":  `var = 1;`,
+		"failing.lox": "print 1;\nimport \"fails.lox\" as f;",
+		"fails.lox":   "fun f() {\n  1 / 0;\n}\nf();",
+	})
+
+	testCases := []struct {
+		name      string
+		file      string
+		wantErr   string
+		wantStack []string
+	}{
+		{
+			name:    "private name",
+			file:    "private.lox",
+			wantErr: "Undefined property '_hidden'.",
+		},
+		{
+			name:    "not found",
+			file:    "missing.lox",
+			wantErr: `Module "nope.lox" not found.`,
+		},
+		{
+			name: "cycle",
+			file: "cycle.lox",
+			wantErr: "Import cycle: " + filepath.Join(dir, "b.lox") + " -> " +
+				filepath.Join(dir, "c.lox") + " -> " + filepath.Join(dir, "b.lox") + ".",
+		},
+		{
+			name:      "module top level",
+			file:      "failing.lox",
+			wantErr:   "Division by zero",
+			wantStack: []string{"<module>:2", "f:4"},
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				err := New(WithBackend(backend)).RunFile(context.Background(), filepath.Join(dir, tc.file))
+
+				var runtimeErr RuntimeError
+				require.ErrorAs(t, err, &runtimeErr)
+				assert.Equal(t, tc.wantErr, runtimeErr.Message)
+
+				if tc.wantStack != nil {
+					var stack []string
+					for _, frame := range runtimeErr.Stack {
+						stack = append(stack, fmt.Sprintf("%s:%d", frame.Function, frame.Call.Line))
+					}
+					assert.Equal(t, tc.wantStack, stack)
+				}
+			})
+		}
+
+		t.Run(string(backend)+"/static error", func(t *testing.T) {
+			err := New(WithBackend(backend)).RunFile(context.Background(), filepath.Join(dir, "broken.lox"))
+
+			var diags DiagnosticList
+			require.ErrorAs(t, err, &diags)
+			require.Len(t, diags, 1)
+			assert.Equal(t, "parse error", diags[0].Kind)
+			assert.Equal(t, filepath.Join(dir, "syntax.lox"), diags[0].File)
+			assert.Equal(t, 1, diags[0].Line)
+			assert.Equal(t, 5, diags[0].Column)
+			assert.False(t, errors.As(err, &RuntimeError{}))
+		})
+	}
+}
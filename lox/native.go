@@ -0,0 +1,319 @@
+package lox
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+type NativeFunc func(args []Value) (Value, error)
+
+// callFunc calls a Lox callable from inside a built-in, whichever backend is
+// running it. Runtime errors raised by the callee propagate as usual.
+type callFunc func(callee Value, args ...Value) Value
+
+type builtinFunc func(call callFunc, args []Value) (Value, error)
+
+func plain(fn NativeFunc) builtinFunc {
+	return func(_ callFunc, args []Value) (Value, error) {
+		return fn(args)
+	}
+}
+
+type nativeFunction[T any] struct {
+	name   string
+	params int
+	fn     builtinFunc
+}
+
+func newNativeFunction[T any](name string, arity int, fn builtinFunc) *nativeFunction[T] {
+	return &nativeFunction[T]{
+		name:   name,
+		params: arity,
+		fn:     fn,
+	}
+}
+
+func (n *nativeFunction[T]) call(i *Interpreter[T], paren *Token, args []any) T {
+	call := func(callee Value, args ...Value) Value {
+		return i.call(callee.(T), paren, args)
+	}
+
+	i.frames = append(i.frames, StackFrame{Function: n.name, Call: paren, Native: true})
+	defer func() {
+		r := recover()
+		if r != nil {
+			r = i.withStack(r)
+		}
+		i.frames = i.frames[:len(i.frames)-1]
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	value, err := n.fn(call, args)
+	if err == nil {
+		err = i.host.checkSize(append(args, value)...)
+	}
+	if err != nil {
+		panic(NewNativeError(paren, err))
+	}
+
+	if value == nil {
+		value = NilT{}
+	}
+
+	return value.(T)
+}
+
+func (n *nativeFunction[T]) arity() int {
+	return n.params
+}
+
+func (n *nativeFunction[T]) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func wrapFunc(fn any) (NativeFunc, int, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return nil, 0, fmt.Errorf("expected a function, got %s", ft)
+	}
+
+	switch {
+	case ft.NumOut() > 2:
+		return nil, 0, fmt.Errorf("function %s returns more than two values", ft)
+	case ft.NumOut() == 2 && ft.Out(1) != errorType:
+		return nil, 0, fmt.Errorf("second result of function %s must be an error", ft)
+	}
+
+	arity := ft.NumIn()
+	if ft.IsVariadic() {
+		arity = -1
+	}
+
+	native := func(args []Value) (Value, error) {
+		if ft.IsVariadic() && len(args) < ft.NumIn()-1 {
+			return nil, fmt.Errorf("Expected at least %d arguments but got %d.", ft.NumIn()-1, len(args))
+		}
+
+		in := make([]reflect.Value, len(args))
+		for n, arg := range args {
+			typ := ft.In(min(n, ft.NumIn()-1))
+			if ft.IsVariadic() && n >= ft.NumIn()-1 {
+				typ = typ.Elem()
+			}
+
+			v, err := toGo(arg, typ)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %w", n+1, err)
+			}
+			in[n] = v
+		}
+
+		out := fv.Call(in)
+		if len(out) > 0 && out[len(out)-1].Type() == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, err
+			}
+			out = out[:len(out)-1]
+		}
+
+		if len(out) == 0 {
+			return NilT{}, nil
+		}
+
+		return fromGo(out[0])
+	}
+
+	return native, arity, nil
+}
+
+// checkNumber reports whether f converts to the numeric type typ without
+// losing its value.
+func checkNumber(f float64, typ reflect.Type) error {
+	v := reflect.Zero(typ)
+	switch typ.Kind() {
+	case reflect.Float32:
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %s", f, typ)
+		}
+		return nil
+	case reflect.Float64:
+		return nil
+	}
+
+	if f != math.Trunc(f) {
+		return fmt.Errorf("expected integer, got %v", f)
+	}
+
+	switch typ.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f < 0 || f >= math.Exp2(64) || v.OverflowUint(uint64(f)) {
+			return fmt.Errorf("%v overflows %s", f, typ)
+		}
+	default:
+		if f < math.MinInt64 || f >= math.Exp2(63) || v.OverflowInt(int64(f)) {
+			return fmt.Errorf("%v overflows %s", f, typ)
+		}
+	}
+	return nil
+}
+
+func toGo(value Value, typ reflect.Type) (reflect.Value, error) {
+	if _, ok := value.(*NilT); ok {
+		value = NilT{}
+	}
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if _, ok := value.(NilT); ok {
+			return reflect.Zero(typ), nil
+		}
+		v := reflect.ValueOf(value)
+		if !v.Type().Implements(typ) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s", value, typ)
+		}
+		return v.Convert(typ), nil
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := value.(float64)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected number, got %v", value)
+		}
+		if err := checkNumber(f, typ); err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(f).Convert(typ), nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected string, got %v", value)
+		}
+		return reflect.ValueOf(s).Convert(typ), nil
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected boolean, got %v", value)
+		}
+		return reflect.ValueOf(b).Convert(typ), nil
+	case reflect.Slice:
+		list, ok := value.(*List)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected list, got %v", value)
+		}
+		out := reflect.MakeSlice(typ, 0, list.Len())
+		for _, elem := range list.Values() {
+			v, err := toGo(elem, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out = reflect.Append(out, v)
+		}
+		return out, nil
+	case reflect.Map:
+		m, ok := value.(*Map)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected map, got %v", value)
+		}
+		out := reflect.MakeMapWithSize(typ, m.Len())
+		for _, key := range m.Keys() {
+			k, err := toGo(key, typ.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, _ := m.Get(key)
+			v, err := toGo(elem, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.SetMapIndex(k, v)
+		}
+		return out, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported Go type %s", typ)
+	}
+}
+
+func fromGo(v reflect.Value) (Value, error) {
+	if !v.IsValid() {
+		return NilT{}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return NilT{}, nil
+		}
+		if v.Kind() == reflect.Interface {
+			return fromGo(v.Elem())
+		}
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return NilT{}, nil
+		}
+		values := make([]Value, 0, v.Len())
+		for n := 0; n < v.Len(); n++ {
+			elem, err := fromGo(v.Index(n))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elem)
+		}
+		return NewList(values...), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return NilT{}, nil
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		})
+
+		m := NewMap()
+		for _, k := range keys {
+			key, err := fromGo(k)
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromGo(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, value)
+		}
+		return m, nil
+	}
+
+	if value, ok := v.Interface().(Value); ok && isLoxValue(value) {
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Go type %s", v.Type())
+}
+
+func isLoxValue(value Value) bool {
+	switch value.(type) {
+	case *List, *Map, NilT, *NilT:
+		return true
+	default:
+		return false
+	}
+}
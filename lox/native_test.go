@@ -0,0 +1,142 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RegisterNative(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			vm := New(WithBackend(backend))
+			vm.RegisterNative("double", 1, func(args []Value) (Value, error) {
+				return args[0].(float64) * 2, nil
+			})
+			vm.RegisterNative("fail", 0, func(args []Value) (Value, error) {
+				return nil, errBoom
+			})
+
+			got, err := vm.Eval(context.Background(), "double(21);")
+			require.NoError(t, err)
+			assert.Equal(t, 42.0, got)
+
+			_, err = vm.Eval(context.Background(), "\n\nfail();")
+			var runtimeErr RuntimeError
+			require.True(t, errors.As(err, &runtimeErr))
+			assert.Equal(t, 3, runtimeErr.Token.Line)
+			assert.ErrorIs(t, err, errBoom)
+		})
+	}
+}
+
+func Test_RegisterFunc(t *testing.T) {
+	testCases := []struct {
+		name  string
+		fn    any
+		input string
+		want  Value
+	}{
+		{
+			name:  "numbers",
+			fn:    func(a int, b float64) float64 { return float64(a) + b },
+			input: "f(1, 2.5);",
+			want:  3.5,
+		},
+		{
+			name:  "strings",
+			fn:    strings.ToUpper,
+			input: `f("abc");`,
+			want:  "ABC",
+		},
+		{
+			name:  "no result",
+			fn:    func(bool) {},
+			input: "f(true);",
+			want:  NilT{},
+		},
+		{
+			name:  "slice",
+			fn:    func(n int) []string { return strings.Split(strings.Repeat("a", n), "") },
+			input: "f(3);",
+			want:  NewList("a", "a", "a"),
+		},
+		{
+			name: "map",
+			fn: func() map[string]int {
+				return map[string]int{"b": 2, "a": 1}
+			},
+			input: "f();",
+			want: func() *Map {
+				m := NewMap()
+				m.Set("a", 1.0)
+				m.Set("b", 2.0)
+				return m
+			}(),
+		},
+		{
+			name:  "variadic",
+			fn:    func(xs ...float64) int { return len(xs) },
+			input: "f(1, 2, 3);",
+			want:  3.0,
+		},
+		{
+			name:  "round trip",
+			fn:    func(xs []int) []int { return append(xs, len(xs)) },
+			input: "f(g());",
+			want:  NewList(1.0, 2.0, 2.0),
+		},
+	}
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				vm := New(WithBackend(backend))
+				require.NoError(t, vm.RegisterFunc("f", tc.fn))
+				require.NoError(t, vm.RegisterFunc("g", func() []int { return []int{1, 2} }))
+
+				got, err := vm.Eval(context.Background(), tc.input)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			})
+		}
+	}
+}
+
+func Test_RegisterFuncErrors(t *testing.T) {
+	vm := New()
+	assert.Error(t, vm.RegisterFunc("f", 42))
+	assert.Error(t, vm.RegisterFunc("f", func() (int, int) { return 0, 0 }))
+
+	require.NoError(t, vm.RegisterFunc("f", func(n int) (int, error) {
+		return 0, fmt.Errorf("bad input %d", n)
+	}))
+	_, err := vm.Eval(context.Background(), "f(7);")
+	assert.EqualError(t, err, "[1]: runtime error at RIGHT_PAREN ): bad input 7")
+
+	_, err = vm.Eval(context.Background(), `f("seven");`)
+	assert.EqualError(t, err, "[1]: runtime error at RIGHT_PAREN ): argument 1: expected number, got seven")
+
+	require.NoError(t, vm.RegisterFunc("g", func(n int8, u uint, f float32) int { return 0 }))
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"g(1.5, 0, 0);", "argument 1: expected integer, got 1.5"},
+		{"g(pow(-1, 0.5), 0, 0);", "argument 1: expected integer, got NaN"},
+		{"g(128, 0, 0);", "argument 1: 128 overflows int8"},
+		{"g(0, -1, 0);", "argument 2: -1 overflows uint"},
+		{"g(0, pow(10, 30), 0);", "argument 2: 1e+30 overflows uint"},
+		{"g(0, 0, pow(2, 200));", "argument 3: 1.6069380442589903e+60 overflows float32"},
+	} {
+		_, err = vm.Eval(context.Background(), tc.input)
+		assert.EqualError(t, err, "[1]: runtime error at RIGHT_PAREN ): "+tc.want, tc.input)
+	}
+}
@@ -0,0 +1,1073 @@
+package lox
+
+import "fmt"
+
+type NilT struct{}
+
+func (n NilT) String() string {
+	return "nil"
+}
+
+type Parser struct {
+	tokens    []*Token
+	current   int
+	diags     DiagnosticList
+	loopDepth int
+}
+
+func newParser(tokens []*Token) *Parser {
+	return &Parser{
+		tokens:  tokens,
+		current: 0,
+	}
+}
+
+func (p *Parser) Parse() ([]Stmt, DiagnosticList) {
+	stmts := make([]Stmt, 0, 0)
+
+	for !p.isEOF() {
+		if stmt := p.declaration(); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts, p.diags
+}
+
+func (p *Parser) declaration() Stmt {
+	var stmt Stmt
+	var err error
+
+	switch true {
+	case p.match(CLASS):
+		stmt, err = p.classDeclaration()
+	case p.check(FUN) && !p.checkNext(LEFT_PAREN):
+		p.advance()
+		stmt, err = p.function("function")
+	case p.match(VAR):
+		stmt, err = p.varDeclaration()
+	case p.match(IMPORT):
+		stmt, err = p.importDeclaration()
+	default:
+		stmt, err = p.statement()
+	}
+
+	if err != nil {
+		p.diags = append(p.diags, newDiagnostic(err))
+		p.synchronize()
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) classDeclaration() (Stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *Variable
+	if p.match(LESS) {
+		if _, err = p.consume(IDENTIFIER, "Expect superclass name."); err != nil {
+			return nil, err
+		}
+		superclass = &Variable{Name: p.previous()}
+	}
+
+	if _, err = p.consume(LEFT_BRACE, "Expect '{' before class body."); err != nil {
+		return nil, err
+	}
+
+	var methods []*Function
+	for !p.check(RIGHT_BRACE) && !p.isEOF() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	if _, err = p.consume(RIGHT_BRACE, "Expect '}' after class body."); err != nil {
+		return nil, err
+	}
+
+	return &Class{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}, nil
+}
+
+func (p *Parser) function(kind string) (*Function, error) {
+	name, err := p.consume(IDENTIFIER, "Expect %s name", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(LEFT_PAREN, "Expect '(' after %s name.", kind); err != nil {
+		return nil, err
+	}
+
+	parameters, err := p.parameters(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts, err := p.functionBody(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Function{
+		Body:   stmts,
+		Name:   name,
+		Params: parameters,
+	}, nil
+}
+
+func (p *Parser) parameters(kind string) ([]*Token, error) {
+	var parameters []*Token
+	if !p.check(RIGHT_PAREN) {
+		for ok := true; ok; ok = p.match(COMMA) {
+			if len(parameters) >= 255 {
+				return nil, NewParseError(p.peek(), "Can't have more than 255 parameters.")
+			}
+			param, err := p.consume(IDENTIFIER, "Expect parameter name.")
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, param)
+		}
+	}
+	if _, err := p.consume(RIGHT_PAREN, "Expect ')' after %s parameters.", kind); err != nil {
+		return nil, err
+	}
+
+	return parameters, nil
+}
+
+func (p *Parser) functionBody(kind string) ([]Stmt, error) {
+	if _, err := p.consume(LEFT_BRACE, "Expect '}' before %s body.", kind); err != nil {
+		return nil, err
+	}
+
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	defer func() {
+		p.loopDepth = loopDepth
+	}()
+
+	return p.blockStatement()
+}
+
+// lambda parses an anonymous function expression after its 'fun' keyword.
+func (p *Parser) lambda() (Expr, error) {
+	keyword := p.previous()
+	if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'fun'."); err != nil {
+		return nil, err
+	}
+
+	params, err := p.parameters("function")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.functionBody("function")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.newLambda(keyword, keyword, params, body), nil
+}
+
+// arrowFunction parses `(a, b) => expr`, `a => expr` or an arrow with a
+// block body.
+func (p *Parser) arrowFunction() (Expr, error) {
+	start := p.advance()
+
+	params := []*Token{start}
+	if start.Type == LEFT_PAREN {
+		var err error
+		if params, err = p.parameters("function"); err != nil {
+			return nil, err
+		}
+	}
+
+	arrow, err := p.consume(ARROW, "Expect '=>' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.check(LEFT_BRACE) {
+		body, err := p.functionBody("function")
+		if err != nil {
+			return nil, err
+		}
+		return p.newLambda(start, arrow, params, body), nil
+	}
+
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	body := []Stmt{&Return{Keyword: arrow, Value: value}}
+	return p.newLambda(start, arrow, params, body), nil
+}
+
+func (p *Parser) newLambda(start, keyword *Token, params []*Token, body []Stmt) *Lambda {
+	name := *start
+	name.Type = IDENTIFIER
+	name.Lexeme = fmt.Sprintf("anonymous@%d", start.Line)
+	name.Comments = nil
+
+	return &Lambda{
+		Keyword:  keyword,
+		Function: &Function{Body: body, Name: &name, Params: params},
+	}
+}
+
+// isArrowFunction reports whether the tokens ahead start an arrow function.
+func (p *Parser) isArrowFunction() bool {
+	n := p.current
+	if p.tokens[n].Type == IDENTIFIER {
+		return p.tokens[n+1].Type == ARROW
+	}
+	if p.tokens[n].Type != LEFT_PAREN {
+		return false
+	}
+
+	n++
+	if p.tokens[n].Type != RIGHT_PAREN {
+		for p.tokens[n].Type == IDENTIFIER && p.tokens[n+1].Type == COMMA {
+			n += 2
+		}
+		if p.tokens[n].Type != IDENTIFIER {
+			return false
+		}
+		n++
+	}
+
+	return p.tokens[n].Type == RIGHT_PAREN && p.tokens[n+1].Type == ARROW
+}
+
+func (p *Parser) varDeclaration() (Stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect variable name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var initializer Expr
+	if p.match(EQUAL) {
+		initializer, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after variable declaration."); err != nil {
+		return nil, err
+	}
+
+	return &Var{
+		Initializer: initializer,
+		Name:        name,
+	}, nil
+}
+
+func (p *Parser) importDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	path, err := p.consume(STRING, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(AS, "Expect 'as' after module path."); err != nil {
+		return nil, err
+	}
+
+	name, err := p.consume(IDENTIFIER, "Expect module name after 'as'.")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after import."); err != nil {
+		return nil, err
+	}
+
+	return &Import{Keyword: keyword, Path: path, Name: name}, nil
+}
+
+func (p *Parser) statement() (Stmt, error) {
+	switch true {
+	case p.match(FOR):
+		return p.forStatement()
+	case p.match(IF):
+		return p.ifStatement()
+	case p.match(PRINT):
+		return p.printStatement()
+	case p.match(RETURN):
+		return p.returnStatement()
+	case p.match(WHILE):
+		return p.whileStatement()
+	case p.match(THROW):
+		return p.throwStatement()
+	case p.match(TRY):
+		return p.tryStatement()
+	case p.match(BREAK):
+		return p.loopControl(&Break{Keyword: p.previous()})
+	case p.match(CONTINUE):
+		return p.loopControl(&Continue{Keyword: p.previous()})
+	case p.match(LEFT_BRACE):
+		stmts, err := p.blockStatement()
+		if err != nil {
+			return nil, err
+		}
+		return &Block{Statements: stmts}, nil
+	default:
+		return p.expressionStatement()
+	}
+}
+
+func (p *Parser) forStatement() (Stmt, error) {
+	var err error
+
+	if _, err = p.consume(LEFT_PAREN, "Expect '(' after 'for'."); err != nil {
+		return nil, err
+	}
+
+	var initializer Stmt
+	if p.match(SEMICOLON) {
+		initializer = nil
+	} else if p.match(VAR) {
+		initializer, err = p.varDeclaration()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		initializer, err = p.expressionStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var condition Expr
+	if !p.check(SEMICOLON) {
+		condition, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(SEMICOLON, "Expect ';' after loop condition."); err != nil {
+		return nil, err
+	}
+
+	var increment Expr
+	if !p.check(RIGHT_PAREN) {
+		increment, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(RIGHT_PAREN, "Expect ')' after for clauses."); err != nil {
+		return nil, err
+	}
+
+	p.loopDepth++
+	body, err := p.statement()
+	p.loopDepth--
+	if err != nil {
+		return nil, err
+	}
+
+	if condition == nil {
+		condition = &Literal{Value: true}
+	}
+	body = &While{Body: body, Condition: condition, Increment: increment}
+
+	if initializer != nil {
+		body = &Block{Statements: []Stmt{initializer, body}}
+	}
+
+	return body, nil
+}
+
+func (p *Parser) ifStatement() (Stmt, error) {
+	if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'if'."); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(RIGHT_PAREN, "Expect ')' after if condition."); err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	var elseBranch Stmt
+	if p.match(ELSE) {
+		elseBranch, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &If{
+		Expression: expr,
+		ThenBranch: thenBranch,
+		ElseBranch: elseBranch,
+	}, nil
+}
+
+func (p *Parser) printStatement() (Stmt, error) {
+	val, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after value."); err != nil {
+		return nil, err
+	}
+
+	return &Print{Expression: val}, nil
+}
+
+func (p *Parser) returnStatement() (Stmt, error) {
+	keyword := p.previous()
+	var value Expr = &NilT{}
+	var err error
+	if !p.check(SEMICOLON) {
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after return value."); err != nil {
+		return nil, err
+	}
+
+	return &Return{
+		Keyword: keyword,
+		Value:   value,
+	}, nil
+}
+
+func (p *Parser) whileStatement() (Stmt, error) {
+	if _, err := p.consume(LEFT_PAREN, "Expect '(' after 'while'."); err != nil {
+		return nil, err
+	}
+
+	cond, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.consume(RIGHT_PAREN, "Expect ')' after condition."); err != nil {
+		return nil, err
+	}
+
+	p.loopDepth++
+	body, err := p.statement()
+	p.loopDepth--
+	if err != nil {
+		return nil, err
+	}
+
+	return &While{
+		Condition: cond,
+		Body:      body,
+	}, nil
+}
+
+func (p *Parser) throwStatement() (Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after thrown value."); err != nil {
+		return nil, err
+	}
+
+	return &Throw{Keyword: keyword, Value: value}, nil
+}
+
+func (p *Parser) tryStatement() (Stmt, error) {
+	stmt := &Try{Keyword: p.previous()}
+
+	var err error
+	if stmt.Body, err = p.block("Expect '{' after 'try'."); err != nil {
+		return nil, err
+	}
+
+	if p.match(CATCH) {
+		if _, err = p.consume(LEFT_PAREN, "Expect '(' after 'catch'."); err != nil {
+			return nil, err
+		}
+		if stmt.CatchName, err = p.consume(IDENTIFIER, "Expect error variable name."); err != nil {
+			return nil, err
+		}
+		if _, err = p.consume(RIGHT_PAREN, "Expect ')' after error variable."); err != nil {
+			return nil, err
+		}
+		if stmt.Catch, err = p.block("Expect '{' after catch clause."); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(FINALLY) {
+		if stmt.Finally, err = p.block("Expect '{' after 'finally'."); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		return nil, NewParseError(p.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+
+	return stmt, nil
+}
+
+// block parses a braced block where one is required rather than any statement.
+func (p *Parser) block(message string) (*Block, error) {
+	if !p.check(LEFT_BRACE) {
+		return nil, NewParseError(p.peek(), message)
+	}
+
+	stmt, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.(*Block), nil
+}
+
+func (p *Parser) loopControl(stmt Stmt) (Stmt, error) {
+	keyword := p.previous()
+	if p.loopDepth == 0 {
+		p.diags = append(p.diags, newDiagnostic(NewParseError(keyword, fmt.Sprintf("Can't use '%s' outside of a loop.", keyword.Lexeme))))
+	}
+
+	if _, err := p.consume(SEMICOLON, "Expect ';' after '%s'.", keyword.Lexeme); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) blockStatement() ([]Stmt, error) {
+	stmts := make([]Stmt, 0)
+	for !p.check(RIGHT_BRACE) && !p.isEOF() {
+		if stmt := p.declaration(); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after block."); err != nil {
+		return nil, err
+	}
+
+	return stmts, nil
+}
+
+func (p *Parser) expressionStatement() (Stmt, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.consume(SEMICOLON, "Expect ';' after expression."); err != nil {
+		return nil, err
+	}
+
+	return &Expression{Expression: expr}, nil
+}
+
+func (p *Parser) expression() (Expr, error) {
+	return p.assignment()
+}
+
+func (p *Parser) assignment() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(EQUAL) {
+		equals := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		switch target := expr.(type) {
+		case *Variable:
+			return &Assign{Name: target.Name, Value: value}, nil
+		case *Get:
+			return &Set{Object: target.Object, Name: target.Name, Value: value}, nil
+		case *Index:
+			return &IndexSet{Object: target.Object, Bracket: target.Bracket, Index: target.Index, Value: value}, nil
+		default:
+			return nil, NewParseError(equals, "Invalid assignment target.")
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) or() (Expr, error) {
+	expr, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(OR) {
+		operator := p.previous()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &Logical{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) and() (Expr, error) {
+	expr, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(AND) {
+		operator := p.previous()
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &Logical{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) equality() (Expr, error) {
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(BANG_EQUAL, EQUAL_EQUAL) {
+		operator := p.previous()
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) match(tokenTypes ...TokenType) bool {
+	for _, tokenType := range tokenTypes {
+		if p.check(tokenType) {
+			p.advance()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Parser) check(tokenType TokenType) bool {
+	if p.isEOF() {
+		return false
+	}
+
+	return p.peek().Type == tokenType
+}
+
+func (p *Parser) advance() *Token {
+	if !p.isEOF() {
+		p.current++
+	}
+
+	return p.previous()
+}
+
+func (p *Parser) isEOF() bool {
+	return p.peek().Type == EOF
+}
+
+func (p *Parser) peek() *Token {
+	return p.tokens[p.current]
+}
+
+// interpolation parses the expressions and string parts of a string that
+// embeds ${...} expressions. The scanner emits an INTERPOLATION token for the
+// first part, an INTERPOLATION_CONT token for every other part followed by an
+// expression and a STRING_CONT token for the last one.
+func (p *Parser) interpolation() (Expr, error) {
+	expr := &Interpolation{Strings: []*Token{p.previous()}}
+	for {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expr.Exprs = append(expr.Exprs, value)
+
+		if p.match(INTERPOLATION_CONT) {
+			expr.Strings = append(expr.Strings, p.previous())
+			continue
+		}
+
+		end, err := p.consume(STRING_CONT, "Expect '}' after interpolated expression.")
+		if err != nil {
+			return nil, err
+		}
+		expr.Strings = append(expr.Strings, end)
+
+		return expr, nil
+	}
+}
+
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isEOF() || p.current+1 >= len(p.tokens) {
+		return false
+	}
+
+	return p.tokens[p.current+1].Type == tokenType
+}
+
+func (p *Parser) previous() *Token {
+	return p.tokens[p.current-1]
+}
+
+func (p *Parser) comparison() (Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+		operator := p.previous()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) term() (Expr, error) {
+	expr, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(MINUS, PLUS) {
+		operator := p.previous()
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) factor() (Expr, error) {
+	expr, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(SLASH, STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) unary() (Expr, error) {
+	if p.match(BANG, MINUS) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Unary{
+			Operator: operator,
+			Right:    right,
+		}, nil
+	}
+
+	return p.call()
+}
+
+func (p *Parser) call() (Expr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match(LEFT_PAREN) {
+			if expr, err = p.finishCall(expr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if p.match(DOT) {
+			name, err := p.consume(IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Get{Object: expr, Name: name}
+			continue
+		}
+		if p.match(LEFT_BRACKET) {
+			bracket := p.previous()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = p.consume(RIGHT_BRACKET, "Expect ']' after index."); err != nil {
+				return nil, err
+			}
+			expr = &Index{Object: expr, Bracket: bracket, Index: index}
+			continue
+		}
+		break
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) finishCall(callee Expr) (Expr, error) {
+	args := make([]Expr, 0)
+	if !p.check(RIGHT_PAREN) {
+		for ok := true; ok; ok = p.match(COMMA) {
+			if len(args) >= 255 {
+				return nil, NewParseError(p.peek(), "Can't have more than 255 arguments")
+			}
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+
+	paren, err := p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Call{
+		Args:   args,
+		Callee: callee,
+		Paren:  paren,
+	}, nil
+}
+
+func (p *Parser) primary() (Expr, error) {
+	if p.match(FALSE) {
+		return &Literal{Value: false}, nil
+	}
+
+	if p.match(TRUE) {
+		return &Literal{Value: true}, nil
+	}
+
+	if p.match(NIL) {
+		return &Literal{Value: NilT{}}, nil
+	}
+
+	if p.match(NUMBER, STRING) {
+		return &Literal{Value: p.previous().Literal, Token: p.previous()}, nil
+	}
+
+	if p.match(INTERPOLATION) {
+		return p.interpolation()
+	}
+
+	if p.match(SUPER) {
+		keyword := p.previous()
+		if _, err := p.consume(DOT, "Expect '.' after 'super'."); err != nil {
+			return nil, err
+		}
+		method, err := p.consume(IDENTIFIER, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return &Super{Keyword: keyword, Method: method}, nil
+	}
+
+	if p.match(THIS) {
+		return &This{Keyword: p.previous()}, nil
+	}
+
+	if p.isArrowFunction() {
+		return p.arrowFunction()
+	}
+
+	if p.match(FUN) {
+		return p.lambda()
+	}
+
+	if p.match(IDENTIFIER) {
+		return &Variable{Name: p.previous()}, nil
+	}
+
+	if p.match(LEFT_BRACKET) {
+		return p.listLiteral()
+	}
+
+	// A '{' that starts a statement is always a block, so a map literal can
+	// only appear where an expression is expected.
+	if p.match(LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
+	if p.match(LEFT_PAREN) {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.consume(RIGHT_PAREN, "expect ')' after expression."); err != nil {
+			return nil, err
+		}
+
+		return &Grouping{Expression: expr}, nil
+	}
+
+	return nil, NewParseError(p.peek(), "expect expression.")
+}
+
+func (p *Parser) listLiteral() (Expr, error) {
+	bracket := p.previous()
+
+	elements := make([]Expr, 0)
+	for !p.check(RIGHT_BRACKET) && !p.isEOF() {
+		element, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	if _, err := p.consume(RIGHT_BRACKET, "Expect ']' after list elements."); err != nil {
+		return nil, err
+	}
+
+	return &ListLiteral{Bracket: bracket, Elements: elements}, nil
+}
+
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+
+	keys := make([]Expr, 0)
+	values := make([]Expr, 0)
+	for !p.check(RIGHT_BRACE) && !p.isEOF() {
+		key, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = p.consume(COLON, "Expect ':' after map key."); err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	if _, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries."); err != nil {
+		return nil, err
+	}
+
+	return &MapLiteral{Brace: brace, Keys: keys, Values: values}, nil
+}
+
+func (p *Parser) consume(t TokenType, message string, args ...any) (*Token, error) {
+	if p.check(t) {
+		return p.advance(), nil
+	}
+
+	return nil, NewParseError(p.peek(), fmt.Sprintf(message, args...))
+}
+
+func (p *Parser) synchronize() {
+	p.advance()
+	for !p.isEOF() {
+		if p.previous().Type == SEMICOLON {
+			return
+		}
+
+		switch p.peek().Type {
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN, IMPORT:
+			return
+		default:
+			p.advance()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseCollectsDiagnostics(t *testing.T) {
+	src := `var a = ;
+print a;
+fun f( { }
+var b = 1 @ 2;
+print (1;
+print b;`
+
+	stmts, diags := Parse("test.lox", src)
+
+	type position struct {
+		Kind    string
+		Line    int
+		Message string
+	}
+	var got []position
+	for _, d := range diags {
+		assert.Equal(t, SeverityError, d.Severity)
+		assert.Equal(t, "test.lox", d.File)
+		got = append(got, position{Kind: d.Kind, Line: d.Line, Message: d.Message})
+	}
+
+	assert.Equal(t, []position{
+		{Kind: "parse error", Line: 1, Message: "expect expression."},
+		{Kind: "parse error", Line: 3, Message: "Expect parameter name."},
+		{Kind: "scan error", Line: 4, Message: "Unexpected character."},
+		{Kind: "parse error", Line: 4, Message: "Expect ';' after variable declaration."},
+		{Kind: "parse error", Line: 5, Message: "expect ')' after expression."},
+	}, got)
+
+	if assert.Len(t, stmts, 2) {
+		assert.IsType(t, &Print{}, stmts[0])
+		assert.IsType(t, &Print{}, stmts[1])
+	}
+}
+
+func Test_ParseLoopControlOutsideLoop(t *testing.T) {
+	src := `break;
+while (true) { if (true) break; else continue; }
+for (;;) { fun f() { continue; } }`
+
+	_, diags := Parse("", src)
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.Message)
+	}
+
+	assert.Equal(t, []string{
+		"Can't use 'break' outside of a loop.",
+		"Can't use 'continue' outside of a loop.",
+	}, got)
+	assert.Equal(t, 1, diags[0].Line)
+	assert.Equal(t, 3, diags[1].Line)
+}
@@ -0,0 +1,359 @@
+package lox
+
+type functionType int
+
+const (
+	functionTypeNone functionType = iota
+	functionTypeFunction
+	functionTypeMethod
+	functionTypeInitializer
+)
+
+type classType int
+
+const (
+	classTypeNone classType = iota
+	classTypeClass
+	classTypeSubclass
+)
+
+type Resolver[T any] struct {
+	interpreter *Interpreter[T]
+	scopes      []map[string]bool
+
+	currentFunction functionType
+	currentClass    classType
+
+	errs []error
+}
+
+func newResolver[T any](interpreter *Interpreter[T]) *Resolver[T] {
+	return &Resolver[T]{
+		interpreter: interpreter,
+	}
+}
+
+func (r *Resolver[T]) Resolve(stmts []Stmt) []error {
+	r.resolveStmts(stmts)
+	return r.errs
+}
+
+func (r *Resolver[T]) resolveStmts(stmts []Stmt) {
+	for _, s := range stmts {
+		r.resolveStmt(s)
+	}
+}
+
+func (r *Resolver[T]) resolveStmt(s Stmt) {
+	AcceptStmtVisitor[any](s, r)
+}
+
+func (r *Resolver[T]) resolveExpr(e Expr) {
+	AcceptExprVisitor[any](e, r)
+}
+
+func (r *Resolver[T]) resolveFunction(fn *Function, typ functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = typ
+	defer func() {
+		r.currentFunction = enclosingFunction
+	}()
+
+	r.beginScope()
+	for _, param := range fn.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolveStmts(fn.Body)
+	r.endScope()
+}
+
+func (r *Resolver[T]) resolveLocal(e Expr, name *Token) {
+	for n := len(r.scopes) - 1; n >= 0; n-- {
+		if _, ok := r.scopes[n][name.Lexeme]; ok {
+			r.interpreter.resolve(e, len(r.scopes)-1-n)
+			return
+		}
+	}
+}
+
+func (r *Resolver[T]) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]bool))
+}
+
+func (r *Resolver[T]) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver[T]) declare(name *Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		r.error(name, "Already a variable with this name in this scope.")
+	}
+
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver[T]) define(name *Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+func (r *Resolver[T]) error(token *Token, message string) {
+	r.errs = append(r.errs, NewResolveError(token, message))
+}
+
+func (r *Resolver[T]) VisitBlockStmt(s *Block) {
+	r.beginScope()
+	r.resolveStmts(s.Statements)
+	r.endScope()
+}
+
+func (r *Resolver[T]) VisitClassStmt(s *Class) {
+	enclosingClass := r.currentClass
+	r.currentClass = classTypeClass
+	defer func() {
+		r.currentClass = enclosingClass
+	}()
+
+	r.declare(s.Name)
+	r.define(s.Name)
+
+	if s.Superclass != nil {
+		if s.Superclass.Name.Lexeme == s.Name.Lexeme {
+			r.error(s.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		r.currentClass = classTypeSubclass
+		r.resolveExpr(s.Superclass)
+
+		r.beginScope()
+		r.scopes[len(r.scopes)-1][superToken.Lexeme] = true
+	}
+
+	r.beginScope()
+	r.scopes[len(r.scopes)-1][thisToken.Lexeme] = true
+
+	for _, method := range s.Methods {
+		typ := functionTypeMethod
+		if method.Name.Lexeme == "init" {
+			typ = functionTypeInitializer
+		}
+		r.resolveFunction(method, typ)
+	}
+
+	r.endScope()
+
+	if s.Superclass != nil {
+		r.endScope()
+	}
+}
+
+func (r *Resolver[T]) VisitExpressionStmt(s *Expression) {
+	r.resolveExpr(s.Expression)
+}
+
+func (r *Resolver[T]) VisitFunctionStmt(s *Function) {
+	r.declare(s.Name)
+	r.define(s.Name)
+	r.resolveFunction(s, functionTypeFunction)
+}
+
+func (r *Resolver[T]) VisitIfStmt(s *If) {
+	r.resolveExpr(s.Expression)
+	r.resolveStmt(s.ThenBranch)
+	if s.ElseBranch != nil {
+		r.resolveStmt(s.ElseBranch)
+	}
+}
+
+func (r *Resolver[T]) VisitPrintStmt(s *Print) {
+	r.resolveExpr(s.Expression)
+}
+
+func (r *Resolver[T]) VisitReturnStmt(s *Return) {
+	if r.currentFunction == functionTypeNone {
+		r.error(s.Keyword, "Can't return from top-level code.")
+	}
+
+	if _, ok := s.Value.(*NilT); !ok {
+		if r.currentFunction == functionTypeInitializer {
+			r.error(s.Keyword, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(s.Value)
+	}
+}
+
+func (r *Resolver[T]) VisitVarStmt(s *Var) {
+	r.declare(s.Name)
+	if s.Initializer != nil {
+		r.resolveExpr(s.Initializer)
+	}
+	r.define(s.Name)
+}
+
+func (r *Resolver[T]) VisitWhileStmt(s *While) {
+	r.resolveExpr(s.Condition)
+	r.resolveStmt(s.Body)
+	if s.Increment != nil {
+		r.resolveExpr(s.Increment)
+	}
+}
+
+func (r *Resolver[T]) VisitImportStmt(s *Import) {
+	r.declare(s.Name)
+	r.define(s.Name)
+}
+
+func (r *Resolver[T]) VisitThrowStmt(s *Throw) {
+	r.resolveExpr(s.Value)
+}
+
+func (r *Resolver[T]) VisitTryStmt(s *Try) {
+	r.resolveStmt(s.Body)
+	if s.Catch != nil {
+		r.beginScope()
+		r.declare(s.CatchName)
+		r.define(s.CatchName)
+		r.resolveStmt(s.Catch)
+		r.endScope()
+	}
+	if s.Finally != nil {
+		r.resolveStmt(s.Finally)
+	}
+}
+
+func (r *Resolver[T]) VisitBreakStmt(s *Break) {}
+
+func (r *Resolver[T]) VisitContinueStmt(s *Continue) {}
+
+func (r *Resolver[T]) VisitAssignExpr(e *Assign) any {
+	r.resolveExpr(e.Value)
+	r.resolveLocal(e, e.Name)
+	return nil
+}
+
+func (r *Resolver[T]) VisitBinaryExpr(e *Binary) any {
+	r.resolveExpr(e.Left)
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver[T]) VisitCallExpr(e *Call) any {
+	r.resolveExpr(e.Callee)
+	for _, arg := range e.Args {
+		r.resolveExpr(arg)
+	}
+	return nil
+}
+
+func (r *Resolver[T]) VisitGetExpr(e *Get) any {
+	r.resolveExpr(e.Object)
+	return nil
+}
+
+func (r *Resolver[T]) VisitLambdaExpr(e *Lambda) any {
+	r.resolveFunction(e.Function, functionTypeFunction)
+	return nil
+}
+
+func (r *Resolver[T]) VisitInterpolationExpr(e *Interpolation) any {
+	for _, expr := range e.Exprs {
+		r.resolveExpr(expr)
+	}
+	return nil
+}
+
+func (r *Resolver[T]) VisitListLiteralExpr(e *ListLiteral) any {
+	for _, element := range e.Elements {
+		r.resolveExpr(element)
+	}
+	return nil
+}
+
+func (r *Resolver[T]) VisitMapLiteralExpr(e *MapLiteral) any {
+	for n := range e.Keys {
+		r.resolveExpr(e.Keys[n])
+		r.resolveExpr(e.Values[n])
+	}
+	return nil
+}
+
+func (r *Resolver[T]) VisitIndexExpr(e *Index) any {
+	r.resolveExpr(e.Object)
+	r.resolveExpr(e.Index)
+	return nil
+}
+
+func (r *Resolver[T]) VisitIndexSetExpr(e *IndexSet) any {
+	r.resolveExpr(e.Value)
+	r.resolveExpr(e.Object)
+	r.resolveExpr(e.Index)
+	return nil
+}
+
+func (r *Resolver[T]) VisitGroupingExpr(e *Grouping) any {
+	r.resolveExpr(e.Expression)
+	return nil
+}
+
+func (r *Resolver[T]) VisitLiteralExpr(e *Literal) any {
+	return nil
+}
+
+func (r *Resolver[T]) VisitLogicalExpr(e *Logical) any {
+	r.resolveExpr(e.Left)
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver[T]) VisitSetExpr(e *Set) any {
+	r.resolveExpr(e.Value)
+	r.resolveExpr(e.Object)
+	return nil
+}
+
+func (r *Resolver[T]) VisitSuperExpr(e *Super) any {
+	switch r.currentClass {
+	case classTypeNone:
+		r.error(e.Keyword, "Can't use 'super' outside of a class.")
+	case classTypeClass:
+		r.error(e.Keyword, "Can't use 'super' in a class with no superclass.")
+	}
+
+	r.resolveLocal(e, e.Keyword)
+	return nil
+}
+
+func (r *Resolver[T]) VisitThisExpr(e *This) any {
+	if r.currentClass == classTypeNone {
+		r.error(e.Keyword, "Can't use 'this' outside of a class.")
+		return nil
+	}
+
+	r.resolveLocal(e, e.Keyword)
+	return nil
+}
+
+func (r *Resolver[T]) VisitUnaryExpr(e *Unary) any {
+	r.resolveExpr(e.Right)
+	return nil
+}
+
+func (r *Resolver[T]) VisitVariableExpr(e *Variable) any {
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][e.Name.Lexeme]; ok && !defined {
+			r.error(e.Name, "Can't read local variable in its own initializer.")
+		}
+	}
+
+	r.resolveLocal(e, e.Name)
+	return nil
+}
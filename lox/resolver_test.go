@@ -0,0 +1,79 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Resolver(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			input: "var a = 1; { var b = a; fun f() { return b; } }",
+			want:  nil,
+		},
+		{
+			name:  "own initializer",
+			input: "{ var a = a; }",
+			want:  []string{"[1]: resolve error at IDENTIFIER a: Can't read local variable in its own initializer."},
+		},
+		{
+			name:  "top-level return",
+			input: "return 1;",
+			want:  []string{"[1]: resolve error at RETURN return: Can't return from top-level code."},
+		},
+		{
+			name:  "duplicate declaration",
+			input: "fun f(a) { var a; }",
+			want:  []string{"[1]: resolve error at IDENTIFIER a: Already a variable with this name in this scope."},
+		},
+		{
+			name:  "this outside of class",
+			input: "print this;",
+			want:  []string{"[1]: resolve error at THIS this: Can't use 'this' outside of a class."},
+		},
+		{
+			name:  "super without superclass",
+			input: "class A { f() { super.f(); } }",
+			want:  []string{"[1]: resolve error at SUPER super: Can't use 'super' in a class with no superclass."},
+		},
+		{
+			name:  "class inherits from itself",
+			input: "class A < A {}",
+			want:  []string{"[1]: resolve error at IDENTIFIER A: A class can't inherit from itself."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stmts := parseSource(t, tc.input)
+			errs := newResolver(NewInterpreter()).Resolve(stmts)
+
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_ResolverDepth(t *testing.T) {
+	stmts := parseSource(t, "{ var a = 1; { print a; } }")
+	interpreter := NewInterpreter()
+	assert.Empty(t, newResolver(interpreter).Resolve(stmts))
+
+	outer := stmts[0].(*Block)
+	inner := outer.Statements[1].(*Block)
+	variable := inner.Statements[0].(*Print).Expression
+
+	depth, ok := interpreter.locals[variable]
+	if assert.True(t, ok) {
+		assert.Equal(t, 1, depth)
+	}
+}
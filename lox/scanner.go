@@ -0,0 +1,396 @@
+package lox
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Scanner struct {
+	source   []rune
+	src      *Source
+	tokens   []*Token
+	comments []*Token
+	diags    DiagnosticList
+
+	// interpolations holds the brace depth of every ${...} being scanned.
+	interpolations []int
+
+	start, current, line int
+
+	lineStart, startLine, startColumn int
+}
+
+func newScanner(file, source string) *Scanner {
+	return &Scanner{
+		source: []rune(source),
+		src:    newSource(file, source),
+		line:   1,
+	}
+}
+
+func (s *Scanner) Scan() ([]*Token, DiagnosticList) {
+	for !s.isEOF() {
+		s.start = s.current
+		s.startLine = s.line
+		s.startColumn = s.current - s.lineStart + 1
+		if err := s.scanToken(); err != nil {
+			s.diags = append(s.diags, newDiagnostic(err))
+		}
+	}
+
+	s.start = s.current
+	s.startLine = s.line
+	s.startColumn = s.current - s.lineStart + 1
+	s.addToken(EOF, nil)
+
+	return s.tokens, s.diags
+}
+
+func (s *Scanner) isEOF() bool {
+	return s.current >= len(s.source)
+}
+
+func (s *Scanner) addToken(typ TokenType, literal interface{}) {
+	t := newToken(typ, string(s.source[s.start:s.current]), literal, s.startLine)
+	t.Column = s.startColumn
+	t.Offset = s.start
+	t.Source = s.src
+	t.Comments = s.comments
+	s.comments = nil
+	s.tokens = append(s.tokens, t)
+}
+
+func (s *Scanner) error(message string) error {
+	t := newToken(EOF, string(s.source[s.start:s.current]), nil, s.startLine)
+	t.Column = s.startColumn
+	t.Offset = s.start
+	t.Source = s.src
+	return NewScanError(t, message)
+}
+
+func (s *Scanner) errorAt(offset int, message string) error {
+	t := newToken(EOF, string(s.source[offset:s.current]), nil, s.line)
+	t.Column = offset - s.lineStart + 1
+	t.Offset = offset
+	t.Source = s.src
+	return NewScanError(t, message)
+}
+
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+}
+
+func (s *Scanner) scanToken() error {
+	char := s.next()
+	switch true {
+	case char == '(':
+		s.addToken(LEFT_PAREN, nil)
+	case char == ')':
+		s.addToken(RIGHT_PAREN, nil)
+	case char == '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
+		s.addToken(LEFT_BRACE, nil)
+	case char == '}':
+		if n := len(s.interpolations); n > 0 {
+			if s.interpolations[n-1] == 0 {
+				s.interpolations = s.interpolations[:n-1]
+				return s.readString(true)
+			}
+			s.interpolations[n-1]--
+		}
+		s.addToken(RIGHT_BRACE, nil)
+	case char == '[':
+		s.addToken(LEFT_BRACKET, nil)
+	case char == ']':
+		s.addToken(RIGHT_BRACKET, nil)
+	case char == ',':
+		s.addToken(COMMA, nil)
+	case char == ':':
+		s.addToken(COLON, nil)
+	case char == '.':
+		s.addToken(DOT, nil)
+	case char == '-':
+		s.addToken(MINUS, nil)
+	case char == '+':
+		s.addToken(PLUS, nil)
+	case char == ';':
+		s.addToken(SEMICOLON, nil)
+	case char == '*':
+		s.addToken(STAR, nil)
+	case char == '!':
+		var typ = BANG
+		if s.nextMatch('=') {
+			typ = BANG_EQUAL
+		}
+		s.addToken(typ, nil)
+	case char == '=':
+		var typ = EQUAL
+		if s.nextMatch('=') {
+			typ = EQUAL_EQUAL
+		} else if s.nextMatch('>') {
+			typ = ARROW
+		}
+		s.addToken(typ, nil)
+	case char == '<':
+		var typ = LESS
+		if s.nextMatch('=') {
+			typ = LESS_EQUAL
+		}
+		s.addToken(typ, nil)
+	case char == '>':
+		var typ = GREATER
+		if s.nextMatch('=') {
+			typ = GREATER_EQUAL
+		}
+		s.addToken(typ, nil)
+	case char == '/':
+		c := s.peek(0)
+		if s.nextMatch('/') || s.nextMatch('*') {
+			s.readComment(c)
+			break
+		}
+		s.addToken(SLASH, nil)
+	case char == '"':
+		return s.readString(false)
+	case char == '`':
+		return s.readRawString()
+	case isDigit(char):
+		return s.readNumber()
+	case isAlpha(char):
+		s.readIdentifier()
+	case char == ' ' || char == '\r' || char == '\t':
+	case char == '\n':
+		s.newLine()
+	default:
+		return s.error("Unexpected character.")
+	}
+
+	return nil
+}
+
+func (s *Scanner) next() rune {
+	char := s.source[s.current]
+	s.current++
+	return char
+}
+
+func (s *Scanner) nextMatch(char rune) bool {
+	if s.isEOF() {
+		return false
+	}
+
+	if s.source[s.current] != char {
+		return false
+	}
+
+	s.current++
+	return true
+}
+
+func (s *Scanner) peek(offset int) rune {
+	if s.isEOF() || s.current+offset >= len(s.source) {
+		return '\000'
+	}
+	return s.source[s.current+offset]
+}
+
+// readString scans a string literal up to its closing quote, or up to the
+// next ${ when the string embeds expressions. cont is set for the part after
+// the '}' that closes an embedded expression.
+func (s *Scanner) readString(cont bool) error {
+	var value strings.Builder
+	for s.peek(0) != '"' && !s.isEOF() {
+		char := s.next()
+		switch {
+		case char == '\n':
+			s.newLine()
+			value.WriteRune(char)
+		case char == '\\':
+			r, err := s.readEscape()
+			if err != nil {
+				s.diags = append(s.diags, newDiagnostic(err))
+				continue
+			}
+			value.WriteRune(r)
+		case char == '$' && s.peek(0) == '{':
+			s.next()
+			if cont {
+				s.addToken(INTERPOLATION_CONT, value.String())
+			} else {
+				s.addToken(INTERPOLATION, value.String())
+			}
+			s.interpolations = append(s.interpolations, 0)
+			return nil
+		default:
+			value.WriteRune(char)
+		}
+	}
+
+	if s.isEOF() {
+		return s.error("Unterminated string.")
+	}
+
+	// the closing "
+	s.next()
+
+	if cont {
+		s.addToken(STRING_CONT, value.String())
+	} else {
+		s.addToken(STRING, value.String())
+	}
+
+	return nil
+}
+
+// readEscape reads the escape sequence after a backslash.
+func (s *Scanner) readEscape() (rune, error) {
+	start := s.current - 1
+	if s.isEOF() {
+		return 0, s.errorAt(start, "Invalid escape sequence.")
+	}
+
+	switch char := s.next(); char {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case '0':
+		return 0, nil
+	case '"', '\\', '$':
+		return char, nil
+	case 'u':
+		return s.readUnicodeEscape(start)
+	default:
+		return 0, s.errorAt(start, "Invalid escape sequence.")
+	}
+}
+
+// readUnicodeEscape reads the code point of a \u{...} escape.
+func (s *Scanner) readUnicodeEscape(start int) (rune, error) {
+	if !s.nextMatch('{') {
+		return 0, s.errorAt(start, "Expect '{' after \\u.")
+	}
+
+	digits := s.current
+	for isHexDigit(s.peek(0)) {
+		s.next()
+	}
+	hex := string(s.source[digits:s.current])
+
+	if !s.nextMatch('}') {
+		return 0, s.errorAt(start, "Expect '}' after unicode escape.")
+	}
+
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) > 6 || !utf8.ValidRune(rune(code)) {
+		return 0, s.errorAt(start, "Invalid unicode code point.")
+	}
+
+	return rune(code), nil
+}
+
+// readRawString scans a string between backticks, which may span lines and
+// has no escape sequences.
+func (s *Scanner) readRawString() error {
+	for s.peek(0) != '`' && !s.isEOF() {
+		if s.next() == '\n' {
+			s.newLine()
+		}
+	}
+
+	if s.isEOF() {
+		return s.error("Unterminated string.")
+	}
+
+	// the closing `
+	s.next()
+
+	s.addToken(STRING, string(s.source[s.start+1:s.current-1]))
+
+	return nil
+}
+
+func (s *Scanner) readNumber() error {
+	for isDigit(s.peek(0)) {
+		s.next()
+	}
+
+	if s.peek(0) == '.' && isDigit(s.peek(1)) {
+		s.next()
+		for isDigit(s.peek(0)) {
+			s.next()
+		}
+	}
+
+	number, err := strconv.ParseFloat(string(s.source[s.start:s.current]), 64)
+	if err != nil {
+		return s.error("Invalid number literal.")
+	}
+
+	s.addToken(NUMBER, number)
+
+	return nil
+}
+
+func (s *Scanner) readIdentifier() {
+	for isAlphaNumeric(s.peek(0)) {
+		s.next()
+	}
+
+	word := string(s.source[s.start:s.current])
+	var typ TokenType = IDENTIFIER
+	if t, ok := reservedWords[word]; ok {
+		typ = t
+	}
+
+	s.addToken(typ, nil)
+}
+
+func (s *Scanner) readComment(char rune) {
+	switch char {
+	case '/':
+		for s.peek(0) != '\n' && !s.isEOF() {
+			s.next()
+		}
+	case '*':
+		for !s.isEOF() {
+			c := s.next()
+			if c == '\n' {
+				s.newLine()
+			}
+			if c == '*' && s.nextMatch('/') {
+				break
+			}
+		}
+	}
+
+	t := newToken(COMMENT, string(s.source[s.start:s.current]), nil, s.startLine)
+	t.Column = s.startColumn
+	t.Offset = s.start
+	t.Source = s.src
+	s.comments = append(s.comments, t)
+}
+
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isHexDigit(char rune) bool {
+	return isDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+}
+
+func isAlpha(char rune) bool {
+	return (char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		char == '_'
+}
+
+func isAlphaNumeric(char rune) bool {
+	return isAlpha(char) || isDigit(char)
+}
@@ -0,0 +1,108 @@
+package lox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ScanStrings(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    []any
+		wantErr []string
+	}{
+		{
+			name:  "escapes",
+			input: `"a\nb\t\"c\"\\\$\0"`,
+			want:  []any{STRING, "a\nb\t\"c\"\\$\x00"},
+		},
+		{
+			name:  "unicode escapes",
+			input: `"\u{41}\u{e9}\u{1F600}"`,
+			want:  []any{STRING, "Aé😀"},
+		},
+		{
+			name:  "raw string",
+			input: "`a\\n${b}\nc`",
+			want:  []any{STRING, "a\\n${b}\nc"},
+		},
+		{
+			name:  "interpolation",
+			input: `"a${b}c${ {"d": 1} }"`,
+			want:  []any{INTERPOLATION, "a", IDENTIFIER, INTERPOLATION_CONT, "c", LEFT_BRACE, STRING, "d", COLON, NUMBER, RIGHT_BRACE, STRING_CONT, ""},
+		},
+		{
+			name:  "nested interpolation",
+			input: `"${"${a}"}"`,
+			want:  []any{INTERPOLATION, "", INTERPOLATION, "", IDENTIFIER, STRING_CONT, "", STRING_CONT, ""},
+		},
+		{
+			name:    "invalid escapes",
+			input:   `"\q\u{110000}\u41"`,
+			want:    []any{STRING, "41"},
+			wantErr: []string{"Invalid escape sequence.", "Invalid unicode code point.", "Expect '{' after \\u."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, diags := newScanner("", tc.input).Scan()
+
+			var got []any
+			for _, token := range tokens[:len(tokens)-1] {
+				got = append(got, token.Type)
+				switch token.Type {
+				case STRING, INTERPOLATION, INTERPOLATION_CONT, STRING_CONT:
+					got = append(got, token.Literal)
+				}
+			}
+
+			var gotErr []string
+			for _, d := range diags {
+				gotErr = append(gotErr, d.Message)
+			}
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantErr, gotErr)
+		})
+	}
+}
+
+func Test_StringInterpolation(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "expressions",
+			input: `var a = 1; "total: ${a + 2}, ${[a]} ${nil} ${true}";`,
+			want:  "total: 3, [1] nil true",
+		},
+		{
+			name:  "same as print",
+			input: `fun f() {} class C {} "${f} ${C} ${C()} ${"s"} ${1.5}";`,
+			want:  "<fn f> C C instance s 1.5",
+		},
+		{
+			name:  "nested",
+			input: `var xs = ["a", "b"]; "${"<${xs[0]}>"}${xs[1]}";`,
+			want:  "<a>b",
+		},
+		{
+			name:    "missing operand",
+			input:   `"${1 +}";`,
+			wantErr: "parse error",
+		},
+		{
+			name:    "missing operand before another expression",
+			input:   `"${1 +}${2}";`,
+			wantErr: "parse error",
+		},
+		{
+			name:    "empty expression",
+			input:   `"${}";`,
+			wantErr: "parse error",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
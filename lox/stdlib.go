@@ -0,0 +1,571 @@
+package lox
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type native struct {
+	name  string
+	arity int
+	fn    builtinFunc
+}
+
+// stdlib holds the built-ins that need no state of their own. The README
+// documents every built-in.
+var stdlib = []native{
+	{name: "sqrt", arity: 1, fn: plain(sqrt)},
+	{name: "floor", arity: 1, fn: plain(floor)},
+	{name: "pow", arity: 2, fn: plain(pow)},
+	{name: "abs", arity: 1, fn: plain(abs)},
+	{name: "min", arity: -1, fn: plain(minimum)},
+	{name: "max", arity: -1, fn: plain(maximum)},
+	{name: "len", arity: 1, fn: plain(length)},
+	{name: "substr", arity: -1, fn: plain(substr)},
+	{name: "upper", arity: 1, fn: plain(upper)},
+	{name: "lower", arity: 1, fn: plain(lower)},
+	{name: "split", arity: 2, fn: plain(split)},
+	{name: "join", arity: 2, fn: plain(join)},
+	{name: "indexOf", arity: 2, fn: plain(indexOf)},
+	{name: "trim", arity: 1, fn: plain(trim)},
+	{name: "replace", arity: 3, fn: plain(replace)},
+	{name: "str", arity: 1, fn: plain(str)},
+	{name: "num", arity: 1, fn: plain(num)},
+	{name: "type", arity: 1, fn: plain(typeOf)},
+	{name: "exit", arity: 1, fn: plain(exit)},
+	{name: "push", arity: 2, fn: plain(push)},
+	{name: "pop", arity: 1, fn: plain(pop)},
+	{name: "slice", arity: -1, fn: plain(slice)},
+	{name: "map", arity: 2, fn: mapList},
+	{name: "filter", arity: 2, fn: filterList},
+	{name: "sort", arity: -1, fn: sortList},
+	{name: "keys", arity: 1, fn: plain(keys)},
+	{name: "values", arity: 1, fn: plain(values)},
+	{name: "has", arity: 2, fn: plain(has)},
+	{name: "delete", arity: 2, fn: plain(deleteKey)},
+}
+
+func sqrt(args []Value) (Value, error) {
+	x, err := numberArg("sqrt", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if x < 0 {
+		return nil, fmt.Errorf("sqrt: expected non-negative number, got %v.", x)
+	}
+	return math.Sqrt(x), nil
+}
+
+func floor(args []Value) (Value, error) {
+	x, err := numberArg("floor", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return math.Floor(x), nil
+}
+
+func pow(args []Value) (Value, error) {
+	x, err := numberArg("pow", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := numberArg("pow", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return math.Pow(x, y), nil
+}
+
+func abs(args []Value) (Value, error) {
+	x, err := numberArg("abs", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return math.Abs(x), nil
+}
+
+func minimum(args []Value) (Value, error) {
+	return extremum("min", args, math.Min)
+}
+
+func maximum(args []Value) (Value, error) {
+	return extremum("max", args, math.Max)
+}
+
+func extremum(name string, args []Value, pick func(x, y float64) float64) (Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s: expected at least 1 argument but got 0.", name)
+	}
+
+	result, err := numberArg(name, args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := 1; n < len(args); n++ {
+		x, err := numberArg(name, args, n)
+		if err != nil {
+			return nil, err
+		}
+		result = pick(result, x)
+	}
+	return result, nil
+}
+
+func length(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case *List:
+		return float64(v.Len()), nil
+	case *Map:
+		return float64(v.Len()), nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	default:
+		return nil, fmt.Errorf("len: expected list, map or string, got %s.", typeName(v))
+	}
+}
+
+func push(args []Value) (Value, error) {
+	list, err := listArg("push", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	list.values = append(list.values, args[1])
+	return nil, nil
+}
+
+func pop(args []Value) (Value, error) {
+	list, err := listArg("pop", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.Len() == 0 {
+		return nil, errors.New("pop: cannot pop from an empty list.")
+	}
+
+	value := list.values[list.Len()-1]
+	list.values = list.values[:list.Len()-1]
+	return value, nil
+}
+
+func slice(args []Value) (Value, error) {
+	if err := checkArgs("slice", args, 2, 3); err != nil {
+		return nil, err
+	}
+
+	list, err := listArg("slice", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := intArg("slice", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	end := list.Len()
+	if len(args) == 3 {
+		if end, err = intArg("slice", args, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	if start < 0 || end > list.Len() || start > end {
+		return nil, fmt.Errorf("slice: bounds [%d:%d] out of range for length %d.", start, end, list.Len())
+	}
+
+	return NewList(slices.Clone(list.values[start:end])...), nil
+}
+
+func mapList(call callFunc, args []Value) (Value, error) {
+	list, err := listArg("map", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Value, 0, list.Len())
+	for _, v := range list.values {
+		values = append(values, call(args[1], v))
+	}
+	return NewList(values...), nil
+}
+
+func filterList(call callFunc, args []Value) (Value, error) {
+	list, err := listArg("filter", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Value, 0)
+	for _, v := range list.values {
+		if toBool(call(args[1], v)) {
+			values = append(values, v)
+		}
+	}
+	return NewList(values...), nil
+}
+
+func sortList(call callFunc, args []Value) (Value, error) {
+	if err := checkArgs("sort", args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	list, err := listArg("sort", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := slices.Clone(list.values)
+	if len(values) == 0 {
+		return NewList(), nil
+	}
+
+	if len(args) == 2 {
+		// less reports whether a goes before b, keeping the first result
+		// that is not a boolean to raise once the sort is done.
+		var err error
+		less := func(a, b Value) bool {
+			result := call(args[1], a, b)
+			isLess, ok := result.(bool)
+			if !ok && err == nil {
+				err = fmt.Errorf("sort: comparison function must return a boolean, got %s.", typeName(result))
+			}
+			return isLess
+		}
+
+		slices.SortStableFunc(values, func(a, b Value) int {
+			switch {
+			case err != nil:
+				return 0
+			case less(a, b):
+				return -1
+			case less(b, a):
+				return 1
+			default:
+				return 0
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewList(values...), nil
+	}
+
+	for _, v := range values {
+		if typeName(v) != typeName(values[0]) {
+			return nil, fmt.Errorf("sort: cannot compare %s and %s.", typeName(values[0]), typeName(v))
+		}
+	}
+
+	switch values[0].(type) {
+	case float64:
+		slices.SortStableFunc(values, func(a, b Value) int {
+			return cmp.Compare(a.(float64), b.(float64))
+		})
+	case string:
+		slices.SortStableFunc(values, func(a, b Value) int {
+			return cmp.Compare(a.(string), b.(string))
+		})
+	default:
+		return nil, fmt.Errorf("sort: cannot compare %s values without a comparison function.", typeName(values[0]))
+	}
+
+	return NewList(values...), nil
+}
+
+func keys(args []Value) (Value, error) {
+	m, err := mapArg("keys", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewList(slices.Clone(m.keys)...), nil
+}
+
+func values(args []Value) (Value, error) {
+	m, err := mapArg("values", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Value, 0, m.Len())
+	for _, key := range m.keys {
+		values = append(values, m.values[key])
+	}
+	return NewList(values...), nil
+}
+
+func has(args []Value) (Value, error) {
+	m, err := mapArg("has", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mapKeyArg("has", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ok := m.Get(key)
+	return ok, nil
+}
+
+func deleteKey(args []Value) (Value, error) {
+	m, err := mapArg("delete", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mapKeyArg("delete", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Delete(key), nil
+}
+
+func substr(args []Value) (Value, error) {
+	if err := checkArgs("substr", args, 2, 3); err != nil {
+		return nil, err
+	}
+
+	s, err := stringArg("substr", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+
+	start, err := intArg("substr", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	end := len(runes)
+	if len(args) == 3 {
+		if end, err = intArg("substr", args, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	if start < 0 || end > len(runes) || start > end {
+		return nil, fmt.Errorf("substr: bounds [%d:%d] out of range for length %d.", start, end, len(runes))
+	}
+
+	return string(runes[start:end]), nil
+}
+
+func upper(args []Value) (Value, error) {
+	s, err := stringArg("upper", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.ToUpper(s), nil
+}
+
+func lower(args []Value) (Value, error) {
+	s, err := stringArg("lower", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.ToLower(s), nil
+}
+
+func split(args []Value) (Value, error) {
+	s, err := stringArg("split", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sep, err := stringArg("split", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(s, sep)
+	values := make([]Value, 0, len(parts))
+	for _, part := range parts {
+		values = append(values, part)
+	}
+	return NewList(values...), nil
+}
+
+func join(args []Value) (Value, error) {
+	list, err := listArg("join", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sep, err := stringArg("join", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, 0, list.Len())
+	for _, v := range list.values {
+		parts = append(parts, stringify(v))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func indexOf(args []Value) (Value, error) {
+	s, err := stringArg("indexOf", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := stringArg("indexOf", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	n := strings.Index(s, sub)
+	if n < 0 {
+		return -1.0, nil
+	}
+	return float64(utf8.RuneCountInString(s[:n])), nil
+}
+
+func trim(args []Value) (Value, error) {
+	s, err := stringArg("trim", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.TrimSpace(s), nil
+}
+
+func replace(args []Value) (Value, error) {
+	s, err := stringArg("replace", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := stringArg("replace", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, err := stringArg("replace", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.ReplaceAll(s, old, replacement), nil
+}
+
+func str(args []Value) (Value, error) {
+	return stringify(args[0]), nil
+}
+
+func num(args []Value) (Value, error) {
+	switch v := args[0].(type) {
+	case float64:
+		return v, nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(n) {
+			return nil, fmt.Errorf("num: cannot parse %q as a number.", v)
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("num: expected string or number, got %s.", typeName(v))
+	}
+}
+
+func typeOf(args []Value) (Value, error) {
+	return typeName(args[0]), nil
+}
+
+// exit ends the script. It unwinds past try statements, so neither catch
+// nor finally blocks run.
+func exit(args []Value) (Value, error) {
+	code, err := intArg("exit", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	panic(ExitError{Code: code})
+}
+
+func checkArgs(name string, args []Value, min, max int) error {
+	if len(args) < min || len(args) > max {
+		return fmt.Errorf("%s: expected %d to %d arguments but got %d.", name, min, max, len(args))
+	}
+	return nil
+}
+
+func listArg(name string, args []Value, n int) (*List, error) {
+	list, ok := args[n].(*List)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected list as argument %d, got %s.", name, n+1, typeName(args[n]))
+	}
+	return list, nil
+}
+
+func mapArg(name string, args []Value, n int) (*Map, error) {
+	m, ok := args[n].(*Map)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected map as argument %d, got %s.", name, n+1, typeName(args[n]))
+	}
+	return m, nil
+}
+
+func mapKeyArg(name string, args []Value, n int) (Value, error) {
+	key, err := mapKey(args[n])
+	if err != nil {
+		got := typeName(args[n])
+		if f, ok := args[n].(float64); ok && math.IsNaN(f) {
+			got = "NaN"
+		}
+		return nil, fmt.Errorf("%s: expected map key as argument %d, got %s.", name, n+1, got)
+	}
+	return key, nil
+}
+
+func numberArg(name string, args []Value, n int) (float64, error) {
+	f, ok := args[n].(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected number as argument %d, got %s.", name, n+1, typeName(args[n]))
+	}
+	return f, nil
+}
+
+func stringArg(name string, args []Value, n int) (string, error) {
+	s, ok := args[n].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: expected string as argument %d, got %s.", name, n+1, typeName(args[n]))
+	}
+	return s, nil
+}
+
+func intArg(name string, args []Value, n int) (int, error) {
+	f, ok := args[n].(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, fmt.Errorf("%s: expected integer as argument %d, got %v.", name, n+1, args[n])
+	}
+	return int(f), nil
+}
+
+func Builtins() map[string]int {
+	natives := newHost().natives()
+	builtins := make(map[string]int, len(natives))
+	for _, native := range natives {
+		builtins[native.name] = native.arity
+	}
+	return builtins
+}
@@ -0,0 +1,392 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Lists(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "literal",
+			input: `[1, "a", nil, true, [2]];`,
+			want:  `[1, "a", nil, true, [2]]`,
+		},
+		{
+			name:  "empty literal with trailing comma",
+			input: `[]; [1, 2,];`,
+			want:  `[1, 2]`,
+		},
+		{
+			name:  "index",
+			input: `var xs = [1, 2, 3]; xs[1] + xs[2];`,
+			want:  `5`,
+		},
+		{
+			name:  "index set",
+			input: `var xs = [1, 2]; xs[0] = xs[1] = 5; xs;`,
+			want:  `[5, 5]`,
+		},
+		{
+			name:    "negative index",
+			input:   `[1][-1];`,
+			wantErr: "List index -1 is negative.",
+		},
+		{
+			name:    "index out of range",
+			input:   `var xs = [1]; xs[1] = 2;`,
+			wantErr: "List index 1 out of range for length 1.",
+		},
+		{
+			name:    "fractional index",
+			input:   `[1][0.5];`,
+			wantErr: "List index must be an integer.",
+		},
+		{
+			name:    "index non list",
+			input:   `var a = 1; a[0];`,
+			wantErr: "Only lists and maps can be indexed.",
+		},
+		{
+			name:  "len",
+			input: `len([1, 2, 3]) + len("héllo");`,
+			want:  `8`,
+		},
+		{
+			name:  "push and pop",
+			input: `var xs = []; push(xs, 1); push(xs, 2); var last = pop(xs); [xs, last];`,
+			want:  `[[1], 2]`,
+		},
+		{
+			name:    "pop empty",
+			input:   `pop([]);`,
+			wantErr: "pop: cannot pop from an empty list.",
+		},
+		{
+			name:  "slice",
+			input: `var xs = [1, 2, 3, 4]; [slice(xs, 1), slice(xs, 1, 3), slice(xs, 2, 2)];`,
+			want:  `[[2, 3, 4], [2, 3], []]`,
+		},
+		{
+			name:    "slice out of range",
+			input:   `slice([1], 0, 2);`,
+			wantErr: "slice: bounds [0:2] out of range for length 1.",
+		},
+		{
+			name:  "map",
+			input: `fun double(x) { return x * 2; } map([1, 2, 3], double);`,
+			want:  `[2, 4, 6]`,
+		},
+		{
+			name:  "filter",
+			input: `fun big(x) { return x > 1; } filter([1, 2, 3], big);`,
+			want:  `[2, 3]`,
+		},
+		{
+			name:  "sort",
+			input: `[sort([3, 1, 2]), sort(["b", "c", "a"])];`,
+			want:  `[[1, 2, 3], ["a", "b", "c"]]`,
+		},
+		{
+			name:  "sort with comparison",
+			input: `fun desc(a, b) { return a > b; } sort([1, 3, 2], desc);`,
+			want:  `[3, 2, 1]`,
+		},
+		{
+			name:    "sort with numeric comparison",
+			input:   `sort([3, 1, 2], (a, b) => a - b);`,
+			wantErr: "sort: comparison function must return a boolean, got number.",
+		},
+		{
+			name:    "sort mixed",
+			input:   `sort([1, "a"]);`,
+			wantErr: "sort: cannot compare number and string.",
+		},
+		{
+			name:    "map with bad callback",
+			input:   `map([1], 2);`,
+			wantErr: "Can only call functions and classes.",
+		},
+		{
+			name:    "wrong argument type",
+			input:   `push(1, 2);`,
+			wantErr: "push: expected list as argument 1, got number.",
+		},
+		{
+			name:  "self reference",
+			input: `var xs = [1]; push(xs, xs); xs;`,
+			want:  `[1, [...]]`,
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_Maps(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "literal",
+			input: `var m = {"a": 1, 2: [true], nil: "n", false: {}}; m;`,
+			want:  `{"a": 1, 2: [true], nil: "n", false: {}}`,
+		},
+		{
+			name:  "empty literal and block",
+			input: `{} var m = {}; m;`,
+			want:  `{}`,
+		},
+		{
+			name:  "lookup and assignment",
+			input: `var m = {"a": 1}; m["b"] = m["a"] + 1; m["a"] = 0; m;`,
+			want:  `{"a": 0, "b": 2}`,
+		},
+		{
+			name:  "duplicate keys keep first position",
+			input: `({"a": 1, "b": 2, "a": 3});`,
+			want:  `{"a": 3, "b": 2}`,
+		},
+		{
+			name:    "missing key",
+			input:   `var m = {"a": 1}; m["b"];`,
+			wantErr: `Undefined key "b".`,
+		},
+		{
+			name:    "unhashable key in literal",
+			input:   `({[1]: 2});`,
+			wantErr: "Map key must be a number, string, boolean or nil, got list.",
+		},
+		{
+			name:    "unhashable key in assignment",
+			input:   `var m = {}; m[m] = 1;`,
+			wantErr: "Map key must be a number, string, boolean or nil, got map.",
+		},
+		{
+			name:    "NaN key",
+			input:   `var m = {}; m[pow(-1, 0.5)] = 1;`,
+			wantErr: "Map key must not be NaN.",
+		},
+		{
+			name:    "has with unhashable key",
+			input:   `has({}, []);`,
+			wantErr: "has: expected map key as argument 2, got list.",
+		},
+		{
+			name:    "delete with NaN key",
+			input:   `delete({}, pow(-1, 0.5));`,
+			wantErr: "delete: expected map key as argument 2, got NaN.",
+		},
+		{
+			name:  "keys and values",
+			input: `var m = {"b": 1, "a": 2}; [keys(m), values(m), len(m)];`,
+			want:  `[["b", "a"], [1, 2], 2]`,
+		},
+		{
+			name:  "has and delete",
+			input: `var m = {"a": 1, "b": 2}; [has(m, "a"), delete(m, "a"), delete(m, "a"), has(m, "a"), m];`,
+			want:  `[true, true, false, false, {"b": 2}]`,
+		},
+		{
+			name:  "iteration",
+			input: `var m = {"x": 1, "y": 2}; var ks = keys(m); var sum = ""; for (var i = 0; i < len(ks); i = i + 1) { sum = sum + ks[i]; } sum;`,
+			want:  `xy`,
+		},
+		{
+			name:    "map literal cannot start a statement",
+			input:   `{"a": 1};`,
+			wantErr: "parse error",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_Math(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "functions",
+			input: `[sqrt(16), floor(-2.5), pow(2, 10), abs(-3), min(3, 1, 2), max(3, 1, 2), min(5)];`,
+			want:  `[4, -3, 1024, 3, 1, 3, 5]`,
+		},
+		{
+			name:  "random in range",
+			input: `var r = random(); r >= 0 and r < 1;`,
+			want:  `true`,
+		},
+		{
+			name:  "seeded random repeats",
+			input: `seed(42); var a = [random(), random()]; seed(42); a[0] == random() and a[1] == random();`,
+			want:  `true`,
+		},
+		{
+			name:    "wrong type",
+			input:   `sqrt("4");`,
+			wantErr: "sqrt: expected number as argument 1, got string.",
+		},
+		{
+			name:    "negative sqrt",
+			input:   `sqrt(-1);`,
+			wantErr: "sqrt: expected non-negative number, got -1.",
+		},
+		{
+			name:    "wrong arity",
+			input:   `pow(2);`,
+			wantErr: "Expected 2 arguments but got 1.",
+		},
+		{
+			name:    "min without arguments",
+			input:   `min();`,
+			wantErr: "min: expected at least 1 argument but got 0.",
+		},
+		{
+			name:    "fractional seed",
+			input:   `seed(1.5);`,
+			wantErr: "seed: expected integer as argument 1, got 1.5.",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_Strings(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "substr",
+			input: `[substr("héllo", 1, 3), substr("héllo", 3)];`,
+			want:  `["él", "lo"]`,
+		},
+		{
+			name:  "case",
+			input: `upper("ab") + lower("CD");`,
+			want:  `ABcd`,
+		},
+		{
+			name:  "split and join",
+			input: `join(split("a,b,c", ","), "-") + join([1, nil, [2]], " ");`,
+			want:  `a-b-c1 nil [2]`,
+		},
+		{
+			name:  "indexOf counts characters",
+			input: `[indexOf("héllo", "l"), indexOf("abc", "z")];`,
+			want:  `[2, -1]`,
+		},
+		{
+			name:  "trim and replace",
+			input: `replace(trim("  a-b-c \n"), "-", "+");`,
+			want:  `a+b+c`,
+		},
+		{
+			name:    "substr out of range",
+			input:   `substr("abc", 2, 5);`,
+			wantErr: "substr: bounds [2:5] out of range for length 3.",
+		},
+		{
+			name:    "wrong type",
+			input:   `upper(1);`,
+			wantErr: "upper: expected string as argument 1, got number.",
+		},
+		{
+			name:    "join needs list",
+			input:   `join("abc", "");`,
+			wantErr: "join: expected list as argument 1, got string.",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_Conversions(t *testing.T) {
+	testCases := []stdlibCase{
+		{
+			name:  "str",
+			input: `str(1.5) + str(nil) + str([1, "a"]);`,
+			want:  `1.5nil[1, "a"]`,
+		},
+		{
+			name:  "num",
+			input: `num(" 42 ") + num("1e3") + num(1);`,
+			want:  `1043`,
+		},
+		{
+			name:  "type",
+			input: `fun f() {} class C {} [type(1), type("a"), type(true), type(nil), type([]), type({}), type(f), type(C), type(C()), type(clock)];`,
+			want:  `["number", "string", "boolean", "nil", "list", "map", "function", "class", "instance", "function"]`,
+		},
+		{
+			name:    "num parse error",
+			input:   `num("abc");`,
+			wantErr: `num: cannot parse "abc" as a number.`,
+		},
+		{
+			name:    "num rejects NaN",
+			input:   `num("NaN");`,
+			wantErr: `num: cannot parse "NaN" as a number.`,
+		},
+		{
+			name:    "num wrong type",
+			input:   `num([]);`,
+			wantErr: "num: expected string or number, got list.",
+		},
+	}
+
+	runStdlibCases(t, testCases)
+}
+
+func Test_Exit(t *testing.T) {
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := New(WithBackend(backend), WithStdout(&out)).Eval(context.Background(), `
+try {
+  print "before";
+  exit(3);
+} catch (e) {
+  print "catch";
+} finally {
+  print "finally";
+}
+print "after";`)
+
+			var exitErr ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, 3, exitErr.Code)
+			assert.Equal(t, "before\n", out.String())
+		})
+	}
+}
+
+type stdlibCase struct {
+	name    string
+	input   string
+	want    string
+	wantErr string
+}
+
+func runStdlibCases(t *testing.T, testCases []stdlibCase) {
+	t.Helper()
+
+	for _, backend := range []Backend{BackendTreeWalker, BackendVM} {
+		for _, tc := range testCases {
+			t.Run(string(backend)+"/"+tc.name, func(t *testing.T) {
+				got, err := New(WithBackend(backend)).Eval(context.Background(), tc.input)
+				if tc.wantErr == "parse error" {
+					var parseErr ParseError
+					require.ErrorAs(t, err, &parseErr)
+					return
+				}
+				if tc.wantErr != "" {
+					var runtimeErr RuntimeError
+					require.ErrorAs(t, err, &runtimeErr)
+					assert.Equal(t, tc.wantErr, runtimeErr.Message)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, fmt.Sprint(got))
+			})
+		}
+	}
+}
@@ -1,6 +1,9 @@
-package main
+package lox
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 type TokenType int
 
@@ -9,7 +12,10 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
+	COLON
 	DOT
 	MINUS
 	PLUS
@@ -21,6 +27,7 @@ const (
 	BANG_EQUAL
 	EQUAL
 	EQUAL_EQUAL
+	ARROW
 	GREATER
 	GREATER_EQUAL
 	LESS
@@ -28,25 +35,39 @@ const (
 
 	IDENTIFIER
 	STRING
+	INTERPOLATION
+	// INTERPOLATION_CONT and STRING_CONT are the parts of an interpolated
+	// string after a '}', up to the next ${ or to the closing quote.
+	INTERPOLATION_CONT
+	STRING_CONT
 	NUMBER
 
 	AND
+	AS
+	BREAK
+	CATCH
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
+	IMPORT
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
 
+	COMMENT
 	EOF
 )
 
@@ -60,8 +81,14 @@ func (t TokenType) String() string {
 		return "LEFT_BRACE"
 	case RIGHT_BRACE:
 		return "RIGHT_BRACE"
+	case LEFT_BRACKET:
+		return "LEFT_BRACKET"
+	case RIGHT_BRACKET:
+		return "RIGHT_BRACKET"
 	case COMMA:
 		return "COMMA"
+	case COLON:
+		return "COLON"
 	case DOT:
 		return "DOT"
 	case MINUS:
@@ -82,6 +109,8 @@ func (t TokenType) String() string {
 		return "EQUAL"
 	case EQUAL_EQUAL:
 		return "EQUAL_EQUAL"
+	case ARROW:
+		return "ARROW"
 	case GREATER:
 		return "GREATER"
 	case GREATER_EQUAL:
@@ -94,22 +123,40 @@ func (t TokenType) String() string {
 		return "IDENTIFIER"
 	case STRING:
 		return "STRING"
+	case INTERPOLATION:
+		return "INTERPOLATION"
+	case INTERPOLATION_CONT:
+		return "INTERPOLATION_CONT"
+	case STRING_CONT:
+		return "STRING_CONT"
 	case NUMBER:
 		return "NUMBER"
 	case AND:
 		return "AND"
+	case AS:
+		return "AS"
+	case BREAK:
+		return "BREAK"
+	case CATCH:
+		return "CATCH"
 	case CLASS:
 		return "CLASS"
+	case CONTINUE:
+		return "CONTINUE"
 	case ELSE:
 		return "ELSE"
 	case FALSE:
 		return "FALSE"
+	case FINALLY:
+		return "FINALLY"
 	case FUN:
 		return "FUN"
 	case FOR:
 		return "FOR"
 	case IF:
 		return "IF"
+	case IMPORT:
+		return "IMPORT"
 	case NIL:
 		return "NIL"
 	case OR:
@@ -122,12 +169,18 @@ func (t TokenType) String() string {
 		return "SUPER"
 	case THIS:
 		return "THIS"
+	case THROW:
+		return "THROW"
 	case TRUE:
 		return "TRUE"
+	case TRY:
+		return "TRY"
 	case VAR:
 		return "VAR"
 	case WHILE:
 		return "WHILE"
+	case COMMENT:
+		return "COMMENT"
 	case EOF:
 		return "EOF"
 	default:
@@ -136,22 +189,39 @@ func (t TokenType) String() string {
 }
 
 var reservedWords = map[string]TokenType{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"for":    FOR,
-	"fun":    FUN,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"var":    VAR,
-	"while":  WHILE,
+	"and":      AND,
+	"as":       AS,
+	"break":    BREAK,
+	"catch":    CATCH,
+	"class":    CLASS,
+	"continue": CONTINUE,
+	"else":     ELSE,
+	"false":    FALSE,
+	"finally":  FINALLY,
+	"for":      FOR,
+	"fun":      FUN,
+	"if":       IF,
+	"import":   IMPORT,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"throw":    THROW,
+	"true":     TRUE,
+	"try":      TRY,
+	"var":      VAR,
+	"while":    WHILE,
+}
+
+func Keywords() []string {
+	keywords := make([]string, 0, len(reservedWords))
+	for word := range reservedWords {
+		keywords = append(keywords, word)
+	}
+	slices.Sort(keywords)
+	return keywords
 }
 
 type Token struct {
@@ -159,6 +229,12 @@ type Token struct {
 	Lexeme  string
 	Literal interface{}
 	Line    int
+	Column  int
+	Offset  int
+	Source  *Source
+
+	// Comments holds the comments between the previous token and this one.
+	Comments []*Token
 }
 
 func newToken(typ TokenType, lexeme string, literal interface{}, line int) *Token {
@@ -0,0 +1,271 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+type Value = any
+
+type List struct {
+	values []Value
+}
+
+func NewList(values ...Value) *List {
+	return &List{values: values}
+}
+
+func (l *List) Len() int {
+	return len(l.values)
+}
+
+func (l *List) Values() []Value {
+	return l.values
+}
+
+func (l *List) String() string {
+	return l.format(make(map[any]bool))
+}
+
+func (l *List) format(seen map[any]bool) string {
+	if seen[l] {
+		return "[...]"
+	}
+	seen[l] = true
+	defer delete(seen, l)
+
+	values := make([]string, 0, len(l.values))
+	for _, v := range l.values {
+		values = append(values, formatNested(v, seen))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(values, ", "))
+}
+
+func (l *List) index(index Value) (int, error) {
+	n, ok := index.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, errors.New("List index must be an integer.")
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("List index %v is negative.", n)
+	}
+	if n >= float64(len(l.values)) {
+		return 0, fmt.Errorf("List index %v out of range for length %d.", n, len(l.values))
+	}
+	return int(n), nil
+}
+
+type Map struct {
+	keys   []Value
+	values map[Value]Value
+}
+
+func NewMap() *Map {
+	return &Map{values: make(map[Value]Value)}
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map) Keys() []Value {
+	return m.keys
+}
+
+func (m *Map) Get(key Value) (Value, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *Map) Set(key, value Value) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *Map) Delete(key Value) bool {
+	if _, ok := m.values[key]; !ok {
+		return false
+	}
+
+	delete(m.values, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k Value) bool {
+		return k == key
+	})
+	return true
+}
+
+func (m *Map) String() string {
+	return m.format(make(map[any]bool))
+}
+
+func (m *Map) format(seen map[any]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+	seen[m] = true
+	defer delete(seen, m)
+
+	entries := make([]string, 0, len(m.keys))
+	for _, k := range m.keys {
+		entries = append(entries, fmt.Sprintf("%s: %s", formatNested(k, seen), formatNested(m.values[k], seen)))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+}
+
+func formatNested(v Value, seen map[any]bool) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case *List:
+		return v.format(seen)
+	case *Map:
+		return v.format(seen)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// mapKey checks that key can be used as a map key. Only values compared by
+// value are hashable, and NaN is not equal even to itself.
+func mapKey(key Value) (Value, error) {
+	switch k := key.(type) {
+	case float64:
+		if math.IsNaN(k) {
+			return nil, errors.New("Map key must not be NaN.")
+		}
+		return key, nil
+	case string, bool, NilT:
+		return key, nil
+	case *NilT:
+		return NilT{}, nil
+	default:
+		return nil, fmt.Errorf("Map key must be a number, string, boolean or nil, got %s.", typeName(key))
+	}
+}
+
+func getIndex(object, index Value) (Value, error) {
+	switch object := object.(type) {
+	case *List:
+		n, err := object.index(index)
+		if err != nil {
+			return nil, err
+		}
+		return object.values[n], nil
+	case *Map:
+		key, err := mapKey(index)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := object.Get(key)
+		if !ok {
+			return nil, fmt.Errorf("Undefined key %s.", formatNested(key, nil))
+		}
+		return value, nil
+	default:
+		return nil, errors.New("Only lists and maps can be indexed.")
+	}
+}
+
+func setIndex(object, index, value Value) error {
+	switch object := object.(type) {
+	case *List:
+		n, err := object.index(index)
+		if err != nil {
+			return err
+		}
+		object.values[n] = value
+		return nil
+	case *Map:
+		key, err := mapKey(index)
+		if err != nil {
+			return err
+		}
+		object.Set(key, value)
+		return nil
+	default:
+		return errors.New("Only lists and maps can be indexed.")
+	}
+}
+
+// Error is the value a catch clause receives for errors raised by the runtime.
+type Error struct {
+	Message string
+	Line    int
+	err     RuntimeError
+}
+
+func caughtValue(err RuntimeError) Value {
+	if err.Value != nil {
+		return err.Value
+	}
+
+	return &Error{
+		Message: err.Message,
+		Line:    err.Token.Line,
+		err:     err,
+	}
+}
+
+func (e *Error) get(name string) (Value, bool) {
+	switch name {
+	case "message":
+		return e.Message, true
+	case "line":
+		return float64(e.Line), true
+	default:
+		return nil, false
+	}
+}
+
+func (e *Error) String() string {
+	return fmt.Sprintf("Error: %s", e.Message)
+}
+
+// stringify formats a value the way print shows it.
+func stringify(v Value) string {
+	return fmt.Sprintf("%v", v)
+}
+
+// isEqual compares values the way == does: nil only equals nil, values of
+// different types are never equal and objects are equal to themselves only.
+func isEqual(a, b Value) bool {
+	aNil, bNil := typeName(a) == "nil", typeName(b) == "nil"
+	if aNil || bNil {
+		return aNil && bNil
+	}
+	return a == b
+}
+
+func typeName(v Value) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case NilT, *NilT, nil:
+		return "nil"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *Error:
+		return "error"
+	case *Module:
+		return "module"
+	case *loxClass[any], *vmClass:
+		return "class"
+	case *loxInstance[any], *vmInstance:
+		return "instance"
+	case loxCallable[any], *vmClosure, *vmNative, *vmBoundMethod:
+		return "function"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,733 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+type vmFunction struct {
+	name         string
+	arity        int
+	upvalueCount int
+	chunk        *Chunk
+	hasResult    bool
+}
+
+func (f *vmFunction) String() string {
+	if f.name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %s>", f.name)
+}
+
+type vmNative struct {
+	name  string
+	arity int
+	fn    builtinFunc
+}
+
+func (n *vmNative) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
+
+type vmUpvalue struct {
+	slot     int
+	closed   any
+	isClosed bool
+	next     *vmUpvalue
+}
+
+type vmClosure struct {
+	function *vmFunction
+	upvalues []*vmUpvalue
+	globals  map[string]any
+}
+
+func (c *vmClosure) String() string {
+	return c.function.String()
+}
+
+type vmClass struct {
+	name    string
+	methods map[string]*vmClosure
+}
+
+func (c *vmClass) String() string {
+	return c.name
+}
+
+type vmInstance struct {
+	class  *vmClass
+	fields map[string]any
+}
+
+func (i *vmInstance) String() string {
+	return fmt.Sprintf("%s instance", i.class.name)
+}
+
+type vmBoundMethod struct {
+	receiver any
+	method   *vmClosure
+}
+
+func (b *vmBoundMethod) String() string {
+	return b.method.String()
+}
+
+type callFrame struct {
+	closure *vmClosure
+	ip      int
+	slots   int
+}
+
+// nativeCall is a built-in running on behalf of the frame at index caller.
+type nativeCall struct {
+	caller int
+	name   string
+}
+
+type vmHandler struct {
+	frame int
+	stack int
+	ip    int
+}
+
+type stackVM struct {
+	frames       []*callFrame
+	stack        []any
+	globals      map[string]any
+	natives      map[string]any
+	host         *host
+	openUpvalues *vmUpvalue
+	handlers     []vmHandler
+	nativeCalls  []nativeCall
+
+	importModule importFunc
+}
+
+func newStackVM(h *host) *stackVM {
+	vm := &stackVM{
+		globals: make(map[string]any),
+		natives: make(map[string]any),
+		host:    h,
+	}
+
+	for _, native := range h.natives() {
+		vm.defineNative(native.name, native.arity, native.fn)
+	}
+
+	return vm
+}
+
+func (vm *stackVM) defineNative(name string, arity int, fn builtinFunc) {
+	native := &vmNative{name: name, arity: arity, fn: fn}
+	vm.natives[name] = native
+	vm.globals[name] = native
+}
+
+// runModule executes the script of an imported file with its own globals.
+func (vm *stackVM) runModule(fn *vmFunction) *Module {
+	globals := make(map[string]any, len(vm.natives))
+	for name, native := range vm.natives {
+		globals[name] = native
+	}
+
+	fn.name = moduleFrame
+	vm.callFunction(&vmClosure{function: fn, globals: globals})
+
+	return &Module{lookup: func(name string) (Value, bool) {
+		value, ok := globals[name]
+		return value, ok
+	}}
+}
+
+func (vm *stackVM) Interpret(fn *vmFunction) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			vm.resetStack()
+			if runtimeErr, ok := r.(RuntimeError); ok {
+				err = runtimeErr
+			} else if haltErr, ok := r.(haltError); ok {
+				err = haltErr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	closure := &vmClosure{function: fn, globals: vm.globals}
+	vm.push(closure)
+	vm.call(closure, 0)
+
+	result := vm.run(0)
+	vm.checkContext()
+	if !fn.hasResult {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func (vm *stackVM) resetStack() {
+	vm.stack = vm.stack[:0]
+	vm.frames = vm.frames[:0]
+	vm.openUpvalues = nil
+	vm.handlers = vm.handlers[:0]
+	vm.nativeCalls = vm.nativeCalls[:0]
+}
+
+func (vm *stackVM) push(value any) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *stackVM) pop() any {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *stackVM) peek(distance int) any {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+func (vm *stackVM) runtimeError(format string, args ...any) error {
+	return NewRuntimeError(vm.currentToken(), fmt.Sprintf(format, args...))
+}
+
+func (vm *stackVM) nativeError(err error) error {
+	return NewNativeError(vm.currentToken(), err)
+}
+
+func (vm *stackVM) currentToken() *Token {
+	return vm.frameToken(vm.frames[len(vm.frames)-1])
+}
+
+// frameToken returns the token frame is executing.
+func (vm *stackVM) frameToken(frame *callFrame) *Token {
+	chunk := frame.closure.function.chunk
+	if token := chunk.Tokens[frame.ip-1]; token != nil {
+		return token
+	}
+
+	token := newToken(EOF, "", nil, chunk.Lines[frame.ip-1])
+	token.Source = chunk.Source
+	return token
+}
+
+// stackTrace returns the active function calls, outermost first.
+func (vm *stackVM) stackTrace() []StackFrame {
+	var stack []StackFrame
+	natives := vm.nativeCalls
+	for n, frame := range vm.frames {
+		if name := frame.closure.function.name; n > 0 && name != "" {
+			stack = append(stack, StackFrame{Function: name, Call: vm.frameToken(vm.frames[n-1])})
+		}
+		for len(natives) > 0 && natives[0].caller == n {
+			stack = append(stack, StackFrame{Function: natives[0].name, Call: vm.frameToken(frame), Native: true})
+			natives = natives[1:]
+		}
+	}
+	return stack
+}
+
+// checkContext stops the script with the active calls as its stack.
+func (vm *stackVM) checkContext() {
+	vm.host.checkContext(vm.stackTrace)
+}
+
+// run executes frames until the frame stack unwinds back to base frames and
+// returns the value of the last returning frame. Runtime errors raised while
+// a handler above base is installed resume execution at that handler.
+func (vm *stackVM) run(base int) any {
+	for {
+		if result, done := vm.runProtected(base); done {
+			return result
+		}
+	}
+}
+
+func (vm *stackVM) runProtected(base int) (result any, done bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if cancelErr, ok := r.(CancelError); ok && cancelErr.Stack == nil {
+				cancelErr.Stack = vm.stackTrace()
+				panic(cancelErr)
+			}
+
+			err, ok := r.(RuntimeError)
+			if !ok {
+				panic(r)
+			}
+			if err.Stack == nil {
+				err.Stack = vm.stackTrace()
+			}
+			if len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frame < base {
+				panic(err)
+			}
+			vm.unwind(caughtValue(err))
+		}
+	}()
+
+	return vm.execute(base), true
+}
+
+// unwind transfers control to the innermost handler with value as the caught
+// error on top of the stack.
+func (vm *stackVM) unwind(value any) {
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.closeUpvalues(handler.stack)
+	vm.stack = vm.stack[:handler.stack]
+	vm.frames = vm.frames[:handler.frame+1]
+	vm.frames[handler.frame].ip = handler.ip
+	for len(vm.nativeCalls) > 0 && vm.nativeCalls[len(vm.nativeCalls)-1].caller >= handler.frame {
+		vm.nativeCalls = vm.nativeCalls[:len(vm.nativeCalls)-1]
+	}
+	vm.push(value)
+}
+
+func (vm *stackVM) execute(base int) any {
+	frame := vm.frames[len(vm.frames)-1]
+	chunk := frame.closure.function.chunk
+
+	readByte := func() byte {
+		b := chunk.Code[frame.ip]
+		frame.ip++
+		return b
+	}
+	readUint16 := func() int {
+		v := chunk.readUint16(frame.ip)
+		frame.ip += 2
+		return v
+	}
+	readConstant := func() any {
+		return chunk.Constants[readUint16()]
+	}
+	readString := func() string {
+		return readConstant().(string)
+	}
+	switchFrame := func() {
+		frame = vm.frames[len(vm.frames)-1]
+		chunk = frame.closure.function.chunk
+	}
+
+	for {
+		vm.host.step()
+		switch op := OpCode(readByte()); op {
+		case OP_CONSTANT:
+			vm.push(readConstant())
+		case OP_NIL:
+			vm.push(NilT{})
+		case OP_TRUE:
+			vm.push(true)
+		case OP_FALSE:
+			vm.push(false)
+		case OP_POP:
+			vm.pop()
+		case OP_GET_LOCAL:
+			vm.push(vm.stack[frame.slots+int(readByte())])
+		case OP_SET_LOCAL:
+			vm.stack[frame.slots+int(readByte())] = vm.peek(0)
+		case OP_GET_GLOBAL:
+			name := readString()
+			value, ok := frame.closure.globals[name]
+			if !ok {
+				panic(vm.runtimeError("Undefined variable"))
+			}
+			vm.push(value)
+		case OP_DEFINE_GLOBAL:
+			frame.closure.globals[readString()] = vm.pop()
+		case OP_SET_GLOBAL:
+			name := readString()
+			if _, ok := frame.closure.globals[name]; !ok {
+				panic(vm.runtimeError("Undefined variable"))
+			}
+			frame.closure.globals[name] = vm.peek(0)
+		case OP_GET_UPVALUE:
+			vm.push(vm.upvalueValue(frame.closure.upvalues[readByte()]))
+		case OP_SET_UPVALUE:
+			vm.setUpvalueValue(frame.closure.upvalues[readByte()], vm.peek(0))
+		case OP_GET_PROPERTY:
+			if ns, ok := vm.peek(0).(namespace); ok {
+				name := readString()
+				value, ok := ns.get(name)
+				if !ok {
+					panic(vm.runtimeError("Undefined property '%s'.", name))
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+
+			instance, ok := vm.peek(0).(*vmInstance)
+			if !ok {
+				panic(vm.runtimeError("Only instances have properties."))
+			}
+
+			name := readString()
+			if value, ok := instance.fields[name]; ok {
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			vm.bindMethod(instance.class, name)
+		case OP_SET_PROPERTY:
+			instance, ok := vm.peek(1).(*vmInstance)
+			if !ok {
+				panic(vm.runtimeError("Only instances have fields."))
+			}
+
+			instance.fields[readString()] = vm.peek(0)
+			value := vm.pop()
+			vm.pop()
+			vm.push(value)
+		case OP_GET_SUPER:
+			name := readString()
+			superclass := vm.pop().(*vmClass)
+			vm.bindMethod(superclass, name)
+		case OP_EQUAL:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(isEqual(a, b))
+		case OP_NOT_EQUAL:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(!isEqual(a, b))
+		case OP_GREATER, OP_GREATER_EQUAL, OP_LESS, OP_LESS_EQUAL, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_ADD:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(vm.binaryOp(op, a, b))
+		case OP_NOT:
+			vm.push(!toBool(vm.pop()))
+		case OP_NEGATE:
+			value, ok := vm.peek(0).(float64)
+			if !ok {
+				panic(vm.runtimeError("Cannot negate %T", vm.peek(0)))
+			}
+			vm.pop()
+			vm.push(-value)
+		case OP_PRINT:
+			fmt.Fprintln(vm.host.stdout, stringify(vm.pop()))
+		case OP_JUMP:
+			offset := readUint16()
+			frame.ip += offset
+		case OP_JUMP_IF_FALSE:
+			offset := readUint16()
+			if !toBool(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case OP_LOOP:
+			offset := readUint16()
+			frame.ip -= offset
+			vm.checkContext()
+		case OP_CALL:
+			argCount := int(readByte())
+			vm.callValue(vm.peek(argCount), argCount)
+			switchFrame()
+		case OP_CLOSURE:
+			fn := readConstant().(*vmFunction)
+			closure := &vmClosure{
+				function: fn,
+				upvalues: make([]*vmUpvalue, fn.upvalueCount),
+				globals:  frame.closure.globals,
+			}
+			for n := range closure.upvalues {
+				isLocal := readByte()
+				index := int(readByte())
+				if isLocal == 1 {
+					closure.upvalues[n] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.upvalues[n] = frame.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			vm.stack = vm.stack[:frame.slots]
+			if len(vm.frames) == base {
+				return result
+			}
+
+			vm.push(result)
+			switchFrame()
+		case OP_CLASS:
+			vm.push(&vmClass{name: readString(), methods: make(map[string]*vmClosure)})
+		case OP_INHERIT:
+			superclass, ok := vm.peek(1).(*vmClass)
+			if !ok {
+				panic(vm.runtimeError("Superclass must be a class."))
+			}
+
+			subclass := vm.peek(0).(*vmClass)
+			for name, method := range superclass.methods {
+				subclass.methods[name] = method
+			}
+			vm.pop()
+		case OP_METHOD:
+			name := readString()
+			class := vm.peek(1).(*vmClass)
+			class.methods[name] = vm.pop().(*vmClosure)
+		case OP_INTERPOLATE:
+			count := readUint16()
+			var b strings.Builder
+			for _, value := range vm.stack[len(vm.stack)-count:] {
+				b.WriteString(stringify(value))
+			}
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			if err := vm.host.checkSize(b.String()); err != nil {
+				panic(vm.nativeError(err))
+			}
+			vm.push(b.String())
+		case OP_LIST:
+			count := readUint16()
+			values := append([]any(nil), vm.stack[len(vm.stack)-count:]...)
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			list := NewList(values...)
+			if err := vm.host.checkSize(list); err != nil {
+				panic(vm.nativeError(err))
+			}
+			vm.push(list)
+		case OP_MAP:
+			count := readUint16()
+			entries := vm.stack[len(vm.stack)-2*count:]
+			m := NewMap()
+			for n := 0; n < len(entries); n += 2 {
+				key, err := mapKey(entries[n])
+				if err != nil {
+					panic(vm.runtimeError("%s", err))
+				}
+				m.Set(key, entries[n+1])
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			if err := vm.host.checkSize(m); err != nil {
+				panic(vm.nativeError(err))
+			}
+			vm.push(m)
+		case OP_GET_INDEX:
+			index := vm.pop()
+			value, err := getIndex(vm.pop(), index)
+			if err != nil {
+				panic(vm.runtimeError("%s", err))
+			}
+			vm.push(value)
+		case OP_SET_INDEX:
+			value := vm.pop()
+			index := vm.pop()
+			object := vm.pop()
+			if err := setIndex(object, index, value); err != nil {
+				panic(vm.runtimeError("%s", err))
+			}
+			if err := vm.host.checkSize(object); err != nil {
+				panic(vm.nativeError(err))
+			}
+			vm.push(value)
+		case OP_TRY:
+			offset := readUint16()
+			vm.handlers = append(vm.handlers, vmHandler{
+				frame: len(vm.frames) - 1,
+				stack: len(vm.stack),
+				ip:    frame.ip + offset,
+			})
+		case OP_END_TRY:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case OP_THROW:
+			panic(NewThrowError(vm.currentToken(), vm.pop()))
+		case OP_IMPORT:
+			path := readString()
+			if vm.importModule == nil {
+				panic(vm.runtimeError("Imports are not supported."))
+			}
+			vm.push(vm.importModule(vm.currentToken(), path))
+		default:
+			panic(vm.runtimeError("Unknown opcode %s.", op))
+		}
+	}
+}
+
+func (vm *stackVM) binaryOp(op OpCode, a, b any) any {
+	if l, ok := a.(string); ok {
+		if r, ok := b.(string); ok {
+			switch op {
+			case OP_ADD:
+				if err := vm.host.checkSize(l + r); err != nil {
+					panic(vm.nativeError(err))
+				}
+				return l + r
+			case OP_GREATER:
+				return l > r
+			case OP_GREATER_EQUAL:
+				return l >= r
+			case OP_LESS:
+				return l < r
+			case OP_LESS_EQUAL:
+				return l <= r
+			}
+		}
+	}
+
+	l, lok := a.(float64)
+	r, rok := b.(float64)
+	if !lok || !rok {
+		panic(vm.runtimeError("Unsupported operands: %v %v", a, b))
+	}
+
+	switch op {
+	case OP_ADD:
+		return l + r
+	case OP_SUBTRACT:
+		return l - r
+	case OP_MULTIPLY:
+		return l * r
+	case OP_DIVIDE:
+		if r == 0 {
+			panic(vm.runtimeError("Division by zero"))
+		}
+		return l / r
+	case OP_GREATER:
+		return l > r
+	case OP_GREATER_EQUAL:
+		return l >= r
+	case OP_LESS:
+		return l < r
+	default:
+		return l <= r
+	}
+}
+
+func (vm *stackVM) callValue(callee any, argCount int) {
+	switch callee := callee.(type) {
+	case *vmClosure:
+		vm.call(callee, argCount)
+	case *vmNative:
+		if callee.arity >= 0 {
+			vm.checkArity(callee.arity, argCount)
+		}
+		args := append([]any(nil), vm.stack[len(vm.stack)-argCount:]...)
+		vm.nativeCalls = append(vm.nativeCalls, nativeCall{caller: len(vm.frames) - 1, name: callee.name})
+		result, err := callee.fn(vm.callFunction, args)
+		if err == nil {
+			err = vm.host.checkSize(append(args, result)...)
+		}
+		if err != nil {
+			panic(vm.nativeError(err))
+		}
+		vm.nativeCalls = vm.nativeCalls[:len(vm.nativeCalls)-1]
+		if result == nil {
+			result = NilT{}
+		}
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		vm.push(result)
+	case *vmClass:
+		vm.stack[len(vm.stack)-argCount-1] = &vmInstance{class: callee, fields: make(map[string]any)}
+		if initializer, ok := callee.methods["init"]; ok {
+			vm.call(initializer, argCount)
+		} else {
+			vm.checkArity(0, argCount)
+		}
+	case *vmBoundMethod:
+		vm.stack[len(vm.stack)-argCount-1] = callee.receiver
+		vm.call(callee.method, argCount)
+	default:
+		panic(vm.runtimeError("Can only call functions and classes."))
+	}
+}
+
+// callFunction calls callee from Go, running the VM until it returns.
+func (vm *stackVM) callFunction(callee any, args ...any) any {
+	base := len(vm.frames)
+
+	vm.push(callee)
+	for _, arg := range args {
+		vm.push(arg)
+	}
+	vm.callValue(callee, len(args))
+
+	if len(vm.frames) == base {
+		return vm.pop()
+	}
+	return vm.run(base)
+}
+
+func (vm *stackVM) call(closure *vmClosure, argCount int) {
+	vm.checkArity(closure.function.arity, argCount)
+
+	// The first frame runs the script, which is not a call.
+	if limit := vm.host.maxCallDepth(); len(vm.frames) > limit {
+		panic(vm.nativeError(StackOverflowError{Limit: limit}))
+	}
+
+	vm.frames = append(vm.frames, &callFrame{
+		closure: closure,
+		slots:   len(vm.stack) - argCount - 1,
+	})
+	vm.checkContext()
+}
+
+func (vm *stackVM) checkArity(arity, argCount int) {
+	if arity != argCount {
+		panic(vm.runtimeError("Expected %d arguments but got %d.", arity, argCount))
+	}
+}
+
+func (vm *stackVM) bindMethod(class *vmClass, name string) {
+	method, ok := class.methods[name]
+	if !ok {
+		panic(vm.runtimeError("Undefined property '%s'.", name))
+	}
+
+	bound := &vmBoundMethod{receiver: vm.peek(0), method: method}
+	vm.pop()
+	vm.push(bound)
+}
+
+func (vm *stackVM) captureUpvalue(slot int) *vmUpvalue {
+	var prev *vmUpvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		prev = upvalue
+		upvalue = upvalue.next
+	}
+
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &vmUpvalue{slot: slot, next: upvalue}
+	if prev == nil {
+		vm.openUpvalues = created
+	} else {
+		prev.next = created
+	}
+
+	return created
+}
+
+func (vm *stackVM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.isClosed = true
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *stackVM) upvalueValue(upvalue *vmUpvalue) any {
+	if upvalue.isClosed {
+		return upvalue.closed
+	}
+	return vm.stack[upvalue.slot]
+}
+
+func (vm *stackVM) setUpvalueValue(upvalue *vmUpvalue, value any) {
+	if upvalue.isClosed {
+		upvalue.closed = value
+		return
+	}
+	vm.stack[upvalue.slot] = value
+}
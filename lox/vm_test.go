@@ -0,0 +1,58 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VMMatchesTreeWalker(t *testing.T) {
+	scripts, err := filepath.Glob("../test_data/*.lox")
+	require.NoError(t, err)
+	require.NotEmpty(t, scripts)
+
+	for _, script := range scripts {
+		t.Run(filepath.Base(script), func(t *testing.T) {
+			src, err := os.ReadFile(script)
+			require.NoError(t, err)
+
+			var want, got bytes.Buffer
+			_, treeErr := New(WithBackend(BackendTreeWalker), WithStdout(&want)).Eval(context.Background(), string(src))
+			_, vmErr := New(WithBackend(BackendVM), WithStdout(&got)).Eval(context.Background(), string(src))
+
+			assert.NoError(t, treeErr)
+			assert.NoError(t, vmErr)
+			assert.Equal(t, want.String(), got.String())
+		})
+	}
+}
+
+func Test_Compiler(t *testing.T) {
+	stmts := parseSource(t, "var a = 1; print a + 2;")
+	fn, errs := newCompiler().Compile(stmts)
+	require.Empty(t, errs)
+
+	var ops []OpCode
+	for offset := 0; offset < len(fn.chunk.Code); {
+		op := OpCode(fn.chunk.Code[offset])
+		ops = append(ops, op)
+		offset = fn.chunk.disassembleInstruction(io.Discard, offset)
+	}
+
+	assert.Equal(t, []OpCode{
+		OP_CONSTANT,
+		OP_DEFINE_GLOBAL,
+		OP_GET_GLOBAL,
+		OP_CONSTANT,
+		OP_ADD,
+		OP_PRINT,
+		OP_NIL,
+		OP_RETURN,
+	}, ops)
+}
@@ -0,0 +1,460 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/unflag/go-lox/lox"
+)
+
+type declKind int
+
+const (
+	declVariable declKind = iota
+	declParameter
+	declFunction
+	declMethod
+	declClass
+	declModule
+)
+
+type declaration struct {
+	name   *lox.Token
+	kind   declKind
+	params []*lox.Token
+}
+
+func (d *declaration) arity() int {
+	return len(d.params)
+}
+
+func (d *declaration) signature() string {
+	params := make([]string, 0, len(d.params))
+	for _, p := range d.params {
+		params = append(params, p.Lexeme)
+	}
+
+	switch d.kind {
+	case declFunction:
+		return fmt.Sprintf("fun %s(%s)", d.name.Lexeme, strings.Join(params, ", "))
+	case declMethod:
+		return fmt.Sprintf("%s(%s)", d.name.Lexeme, strings.Join(params, ", "))
+	case declClass:
+		return fmt.Sprintf("class %s", d.name.Lexeme)
+	case declModule:
+		return fmt.Sprintf("module %s", d.name.Lexeme)
+	case declParameter:
+		return fmt.Sprintf("(parameter) %s", d.name.Lexeme)
+	default:
+		return fmt.Sprintf("var %s", d.name.Lexeme)
+	}
+}
+
+type occurrence struct {
+	token *lox.Token
+	decl  *declaration
+}
+
+type document struct {
+	uri   string
+	lines []string
+	diags lox.DiagnosticList
+
+	decls       []*declaration
+	occurrences []occurrence
+	symbols     []DocumentSymbol
+}
+
+func newDocument(uri, text string) *document {
+	stmts, diags := lox.Parse(uri, text)
+
+	doc := &document{
+		uri:   uri,
+		lines: strings.Split(text, "\n"),
+		diags: diags,
+	}
+
+	a := &analyzer{
+		doc:        doc,
+		globals:    make(map[string]*declaration),
+		globalRefs: make(map[string][]*lox.Token),
+		symbols:    &doc.symbols,
+	}
+	a.walkStmts(stmts)
+	a.resolveGlobals()
+
+	return doc
+}
+
+func (d *document) position(line, column int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{Line: max(line-1, 0)}
+	}
+
+	runes := []rune(d.lines[line-1])
+	column = min(max(column-1, 0), len(runes))
+	return Position{
+		Line:      line - 1,
+		Character: len(utf16.Encode(runes[:column])),
+	}
+}
+
+func (d *document) column(pos Position) int {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return 0
+	}
+
+	units := 0
+	for n, r := range []rune(d.lines[pos.Line]) {
+		if units >= pos.Character {
+			return n + 1
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len([]rune(d.lines[pos.Line])) + 1
+}
+
+func (d *document) tokenRange(t *lox.Token) Range {
+	return Range{
+		Start: d.position(t.Line, t.Column),
+		End:   d.position(t.Line, t.Column+len([]rune(t.Lexeme))),
+	}
+}
+
+func (d *document) occurrenceAt(pos Position) (occurrence, bool) {
+	column := d.column(pos)
+	for _, o := range d.occurrences {
+		if o.token.Line != pos.Line+1 {
+			continue
+		}
+		if column >= o.token.Column && column <= o.token.Column+len([]rune(o.token.Lexeme)) {
+			return o, true
+		}
+	}
+	return occurrence{}, false
+}
+
+func (d *document) references(decl *declaration) []*lox.Token {
+	var tokens []*lox.Token
+	for _, o := range d.occurrences {
+		if o.decl == decl && o.token != decl.name {
+			tokens = append(tokens, o.token)
+		}
+	}
+	return tokens
+}
+
+type analyzer struct {
+	doc        *document
+	globals    map[string]*declaration
+	globalRefs map[string][]*lox.Token
+	scopes     []map[string]*declaration
+	symbols    *[]DocumentSymbol
+}
+
+func (a *analyzer) walkStmts(stmts []lox.Stmt) {
+	for _, s := range stmts {
+		a.walkStmt(s)
+	}
+}
+
+func (a *analyzer) walkStmt(s lox.Stmt) {
+	if s != nil {
+		lox.AcceptStmtVisitor[any](s, a)
+	}
+}
+
+func (a *analyzer) walkExpr(e lox.Expr) {
+	if e != nil {
+		lox.AcceptExprVisitor[any](e, a)
+	}
+}
+
+func (a *analyzer) beginScope() {
+	a.scopes = append(a.scopes, make(map[string]*declaration))
+}
+
+func (a *analyzer) endScope() {
+	a.scopes = a.scopes[:len(a.scopes)-1]
+}
+
+func (a *analyzer) declare(name *lox.Token, kind declKind, params []*lox.Token) *declaration {
+	scope := a.globals
+	if len(a.scopes) > 0 {
+		scope = a.scopes[len(a.scopes)-1]
+	}
+
+	decl, ok := scope[name.Lexeme]
+	if !ok || len(a.scopes) > 0 {
+		decl = &declaration{name: name, kind: kind, params: params}
+		scope[name.Lexeme] = decl
+		a.doc.decls = append(a.doc.decls, decl)
+	}
+
+	a.doc.occurrences = append(a.doc.occurrences, occurrence{token: name, decl: decl})
+	return decl
+}
+
+func (a *analyzer) reference(name *lox.Token) {
+	for n := len(a.scopes) - 1; n >= 0; n-- {
+		if decl, ok := a.scopes[n][name.Lexeme]; ok {
+			a.doc.occurrences = append(a.doc.occurrences, occurrence{token: name, decl: decl})
+			return
+		}
+	}
+
+	a.globalRefs[name.Lexeme] = append(a.globalRefs[name.Lexeme], name)
+}
+
+func (a *analyzer) resolveGlobals() {
+	for name, tokens := range a.globalRefs {
+		decl, ok := a.globals[name]
+		if !ok {
+			continue
+		}
+		for _, t := range tokens {
+			a.doc.occurrences = append(a.doc.occurrences, occurrence{token: t, decl: decl})
+		}
+	}
+}
+
+func (a *analyzer) addSymbol(decl *declaration, kind SymbolKind, children func()) {
+	symbol := DocumentSymbol{
+		Name:           decl.name.Lexeme,
+		Detail:         decl.signature(),
+		Kind:           kind,
+		Range:          a.doc.tokenRange(decl.name),
+		SelectionRange: a.doc.tokenRange(decl.name),
+	}
+
+	if children != nil {
+		parent := a.symbols
+		a.symbols = &symbol.Children
+		children()
+		a.symbols = parent
+	}
+
+	*a.symbols = append(*a.symbols, symbol)
+}
+
+func (a *analyzer) function(fn *lox.Function, kind declKind) {
+	var decl *declaration
+	if kind == declMethod {
+		decl = &declaration{name: fn.Name, kind: kind, params: fn.Params}
+		a.doc.decls = append(a.doc.decls, decl)
+	} else {
+		decl = a.declare(fn.Name, kind, fn.Params)
+	}
+
+	symbolKind := SymbolKindFunction
+	if kind == declMethod {
+		symbolKind = SymbolKindMethod
+	}
+
+	a.addSymbol(decl, symbolKind, func() {
+		a.beginScope()
+		for _, p := range fn.Params {
+			a.declare(p, declParameter, nil)
+		}
+		a.walkStmts(fn.Body)
+		a.endScope()
+	})
+}
+
+func (a *analyzer) VisitBlockStmt(s *lox.Block) {
+	a.beginScope()
+	a.walkStmts(s.Statements)
+	a.endScope()
+}
+
+func (a *analyzer) VisitClassStmt(s *lox.Class) {
+	var params []*lox.Token
+	for _, m := range s.Methods {
+		if m.Name.Lexeme == "init" {
+			params = m.Params
+		}
+	}
+
+	decl := a.declare(s.Name, declClass, params)
+	if s.Superclass != nil {
+		a.reference(s.Superclass.Name)
+	}
+
+	a.addSymbol(decl, SymbolKindClass, func() {
+		for _, m := range s.Methods {
+			a.function(m, declMethod)
+		}
+	})
+}
+
+func (a *analyzer) VisitExpressionStmt(s *lox.Expression) {
+	a.walkExpr(s.Expression)
+}
+
+func (a *analyzer) VisitFunctionStmt(s *lox.Function) {
+	a.function(s, declFunction)
+}
+
+func (a *analyzer) VisitIfStmt(s *lox.If) {
+	a.walkExpr(s.Expression)
+	a.walkStmt(s.ThenBranch)
+	a.walkStmt(s.ElseBranch)
+}
+
+func (a *analyzer) VisitPrintStmt(s *lox.Print) {
+	a.walkExpr(s.Expression)
+}
+
+func (a *analyzer) VisitReturnStmt(s *lox.Return) {
+	a.walkExpr(s.Value)
+}
+
+func (a *analyzer) VisitVarStmt(s *lox.Var) {
+	a.walkExpr(s.Initializer)
+	decl := a.declare(s.Name, declVariable, nil)
+	a.addSymbol(decl, SymbolKindVariable, nil)
+}
+
+func (a *analyzer) VisitWhileStmt(s *lox.While) {
+	a.walkExpr(s.Condition)
+	a.walkStmt(s.Body)
+	if s.Increment != nil {
+		a.walkExpr(s.Increment)
+	}
+}
+
+func (a *analyzer) VisitImportStmt(s *lox.Import) {
+	decl := a.declare(s.Name, declModule, nil)
+	a.addSymbol(decl, SymbolKindModule, nil)
+}
+
+func (a *analyzer) VisitThrowStmt(s *lox.Throw) {
+	a.walkExpr(s.Value)
+}
+
+func (a *analyzer) VisitTryStmt(s *lox.Try) {
+	a.walkStmt(s.Body)
+	if s.Catch != nil {
+		a.beginScope()
+		a.declare(s.CatchName, declVariable, nil)
+		a.walkStmt(s.Catch)
+		a.endScope()
+	}
+	if s.Finally != nil {
+		a.walkStmt(s.Finally)
+	}
+}
+
+func (a *analyzer) VisitBreakStmt(s *lox.Break) {}
+
+func (a *analyzer) VisitContinueStmt(s *lox.Continue) {}
+
+func (a *analyzer) VisitAssignExpr(e *lox.Assign) any {
+	a.walkExpr(e.Value)
+	a.reference(e.Name)
+	return nil
+}
+
+func (a *analyzer) VisitBinaryExpr(e *lox.Binary) any {
+	a.walkExpr(e.Left)
+	a.walkExpr(e.Right)
+	return nil
+}
+
+func (a *analyzer) VisitCallExpr(e *lox.Call) any {
+	a.walkExpr(e.Callee)
+	for _, arg := range e.Args {
+		a.walkExpr(arg)
+	}
+	return nil
+}
+
+func (a *analyzer) VisitGetExpr(e *lox.Get) any {
+	a.walkExpr(e.Object)
+	return nil
+}
+
+func (a *analyzer) VisitGroupingExpr(e *lox.Grouping) any {
+	a.walkExpr(e.Expression)
+	return nil
+}
+
+func (a *analyzer) VisitLiteralExpr(e *lox.Literal) any {
+	return nil
+}
+
+func (a *analyzer) VisitLogicalExpr(e *lox.Logical) any {
+	a.walkExpr(e.Left)
+	a.walkExpr(e.Right)
+	return nil
+}
+
+func (a *analyzer) VisitSetExpr(e *lox.Set) any {
+	a.walkExpr(e.Object)
+	a.walkExpr(e.Value)
+	return nil
+}
+
+func (a *analyzer) VisitLambdaExpr(e *lox.Lambda) any {
+	a.beginScope()
+	for _, p := range e.Function.Params {
+		a.declare(p, declParameter, nil)
+	}
+	a.walkStmts(e.Function.Body)
+	a.endScope()
+	return nil
+}
+
+func (a *analyzer) VisitInterpolationExpr(e *lox.Interpolation) any {
+	for _, expr := range e.Exprs {
+		a.walkExpr(expr)
+	}
+	return nil
+}
+
+func (a *analyzer) VisitListLiteralExpr(e *lox.ListLiteral) any {
+	for _, element := range e.Elements {
+		a.walkExpr(element)
+	}
+	return nil
+}
+
+func (a *analyzer) VisitMapLiteralExpr(e *lox.MapLiteral) any {
+	for n := range e.Keys {
+		a.walkExpr(e.Keys[n])
+		a.walkExpr(e.Values[n])
+	}
+	return nil
+}
+
+func (a *analyzer) VisitIndexExpr(e *lox.Index) any {
+	a.walkExpr(e.Object)
+	a.walkExpr(e.Index)
+	return nil
+}
+
+func (a *analyzer) VisitIndexSetExpr(e *lox.IndexSet) any {
+	a.walkExpr(e.Object)
+	a.walkExpr(e.Index)
+	a.walkExpr(e.Value)
+	return nil
+}
+
+func (a *analyzer) VisitSuperExpr(e *lox.Super) any {
+	return nil
+}
+
+func (a *analyzer) VisitThisExpr(e *lox.This) any {
+	return nil
+}
+
+func (a *analyzer) VisitUnaryExpr(e *lox.Unary) any {
+	a.walkExpr(e.Right)
+	return nil
+}
+
+func (a *analyzer) VisitVariableExpr(e *lox.Variable) any {
+	a.reference(e.Name)
+	return nil
+}
@@ -0,0 +1,343 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/unflag/go-lox/lox"
+)
+
+type Server struct {
+	r *bufio.Reader
+	w io.Writer
+
+	docs     map[string]*document
+	shutdown bool
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		r:    bufio.NewReader(r),
+		w:    w,
+		docs: make(map[string]*document),
+	}
+}
+
+func (s *Server) Run() error {
+	for {
+		req, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) read() (*request, error) {
+	header, err := textproto.NewReader(s.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return nil, fmt.Errorf("could not read message body: %w", err)
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return &request{}, s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+	}
+
+	return &req, nil
+}
+
+func (s *Server) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, respErr *responseError) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: respErr})
+}
+
+func (s *Server) notify(method string, params any) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req *request) error {
+	var (
+		result any
+		err    error
+	)
+
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized", "$/cancelRequest", "$/setTrace":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			err = s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			err = s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			delete(s.docs, params.TextDocument.URI)
+			err = s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+				URI:         params.TextDocument.URI,
+				Diagnostics: []Diagnostic{},
+			})
+		}
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.documentSymbol(params)
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/references":
+		var params referenceParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.references(params)
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.completion(params)
+		}
+	default:
+		if req.ID != nil {
+			return s.reply(req.ID, nil, &responseError{
+				Code:    codeMethodNotFound,
+				Message: fmt.Sprintf("method not found: %s", req.Method),
+			})
+		}
+		return nil
+	}
+
+	if req.ID == nil {
+		return err
+	}
+
+	if err != nil {
+		return s.reply(req.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+	}
+
+	return s.reply(req.ID, result, nil)
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync":       1,
+			"documentSymbolProvider": true,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"completionProvider":     map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name": "lox-lsp",
+		},
+	}
+}
+
+func (s *Server) update(uri, text string) error {
+	doc := newDocument(uri, text)
+	s.docs[uri] = doc
+
+	diags := make([]Diagnostic, 0, len(doc.diags))
+	for _, d := range doc.diags {
+		start := doc.position(d.Line, d.Column)
+		end := doc.position(d.Line, d.Column+d.Length)
+		if d.Column == 0 {
+			end = doc.position(d.Line, len([]rune(doc.lines[max(d.Line-1, 0)]))+1)
+		}
+
+		severity := 1
+		if d.Severity == lox.SeverityWarning {
+			severity = 2
+		}
+
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: severity,
+			Source:   "lox",
+			Message:  fmt.Sprintf("%s: %s", d.Kind, d.Message),
+		})
+	}
+
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diags,
+	})
+}
+
+func (s *Server) documentSymbol(params documentSymbolParams) []DocumentSymbol {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return []DocumentSymbol{}
+	}
+
+	return doc.symbols
+}
+
+func (s *Server) definition(params textDocumentPositionParams) any {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	o, ok := doc.occurrenceAt(params.Position)
+	if !ok {
+		return nil
+	}
+
+	return Location{URI: doc.uri, Range: doc.tokenRange(o.decl.name)}
+}
+
+func (s *Server) references(params referenceParams) []Location {
+	locations := []Location{}
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return locations
+	}
+
+	o, ok := doc.occurrenceAt(params.Position)
+	if !ok {
+		return locations
+	}
+
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, Location{URI: doc.uri, Range: doc.tokenRange(o.decl.name)})
+	}
+
+	for _, t := range doc.references(o.decl) {
+		locations = append(locations, Location{URI: doc.uri, Range: doc.tokenRange(t)})
+	}
+
+	return locations
+}
+
+func (s *Server) hover(params textDocumentPositionParams) any {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	o, ok := doc.occurrenceAt(params.Position)
+	if !ok {
+		return nil
+	}
+
+	value := fmt.Sprintf("```lox\n%s\n```", o.decl.signature())
+	switch o.decl.kind {
+	case declFunction, declMethod, declClass:
+		value += fmt.Sprintf("\n\narity: %d", o.decl.arity())
+	}
+
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: value},
+		Range:    doc.tokenRange(o.token),
+	}
+}
+
+func (s *Server) completion(params textDocumentPositionParams) []CompletionItem {
+	var items []CompletionItem
+	for _, keyword := range lox.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionItemKindKeyword})
+	}
+
+	builtins := lox.Builtins()
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   CompletionItemKindFunction,
+			Detail: fmt.Sprintf("native fn %s, arity %d", name, builtins[name]),
+		})
+	}
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return items
+	}
+
+	seen := make(map[string]bool)
+	for _, decl := range doc.decls {
+		if seen[decl.name.Lexeme] || decl.kind == declMethod {
+			continue
+		}
+		seen[decl.name.Lexeme] = true
+
+		kind := CompletionItemKindVariable
+		switch decl.kind {
+		case declFunction:
+			kind = CompletionItemKindFunction
+		case declClass:
+			kind = CompletionItemKindClass
+		}
+
+		items = append(items, CompletionItem{
+			Label:  decl.name.Lexeme,
+			Kind:   kind,
+			Detail: strings.TrimSpace(decl.signature()),
+		})
+	}
+
+	return items
+}
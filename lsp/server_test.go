@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type client struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	id  int
+	err chan error
+}
+
+func newClient(t *testing.T) *client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	c := &client{t: t, w: clientW, r: bufio.NewReader(clientR), err: make(chan error, 1)}
+	go func() {
+		c.err <- NewServer(serverR, serverW).Run()
+		serverW.Close()
+	}()
+
+	return c
+}
+
+func (c *client) send(msg map[string]any) {
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(c.t, err)
+}
+
+func (c *client) receive() map[string]any {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	require.NoError(c.t, err)
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.r, body)
+	require.NoError(c.t, err)
+
+	var msg map[string]any
+	require.NoError(c.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+func (c *client) request(method string, params any) map[string]any {
+	c.id++
+	c.send(map[string]any{"id": c.id, "method": method, "params": params})
+	msg := c.receive()
+	require.EqualValues(c.t, c.id, msg["id"])
+	return msg
+}
+
+func (c *client) notify(method string, params any) {
+	c.send(map[string]any{"method": method, "params": params})
+}
+
+func (c *client) roundTrip(v any, out any) {
+	body, err := json.Marshal(v)
+	require.NoError(c.t, err)
+	require.NoError(c.t, json.Unmarshal(body, out))
+}
+
+const testURI = "file:///test.lox"
+
+const testSource = `fun add(a, b) {
+  return a + b;
+}
+
+class Point {
+  init(x) {
+    this.x = x;
+  }
+}
+
+var total = add(1, 2);
+print total;
+`
+
+func position(line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func Test_Server(t *testing.T) {
+	c := newClient(t)
+
+	init := c.request("initialize", map[string]any{})
+	caps := init["result"].(map[string]any)["capabilities"].(map[string]any)
+	require.EqualValues(t, 1, caps["textDocumentSync"])
+	require.Equal(t, true, caps["hoverProvider"])
+	c.notify("initialized", map[string]any{})
+
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": testURI, "languageId": "lox", "version": 1, "text": testSource},
+	})
+	var published publishDiagnosticsParams
+	c.roundTrip(c.receive()["params"], &published)
+	require.Equal(t, testURI, published.URI)
+	require.Empty(t, published.Diagnostics)
+
+	t.Run("documentSymbol", func(t *testing.T) {
+		var symbols []DocumentSymbol
+		c.roundTrip(c.request("textDocument/documentSymbol", map[string]any{
+			"textDocument": map[string]any{"uri": testURI},
+		})["result"], &symbols)
+
+		require.Len(t, symbols, 3)
+		require.Equal(t, "add", symbols[0].Name)
+		require.Equal(t, SymbolKindFunction, symbols[0].Kind)
+		require.Equal(t, "Point", symbols[1].Name)
+		require.Equal(t, SymbolKindClass, symbols[1].Kind)
+		require.Len(t, symbols[1].Children, 1)
+		require.Equal(t, "init", symbols[1].Children[0].Name)
+		require.Equal(t, SymbolKindMethod, symbols[1].Children[0].Kind)
+		require.Equal(t, "total", symbols[2].Name)
+		require.Equal(t, SymbolKindVariable, symbols[2].Kind)
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var location Location
+		c.roundTrip(c.request("textDocument/definition", position(10, 13))["result"], &location)
+		require.Equal(t, Location{
+			URI:   testURI,
+			Range: Range{Start: Position{Line: 0, Character: 4}, End: Position{Line: 0, Character: 7}},
+		}, location)
+
+		c.roundTrip(c.request("textDocument/definition", position(1, 14))["result"], &location)
+		require.Equal(t, Range{Start: Position{Line: 0, Character: 11}, End: Position{Line: 0, Character: 12}}, location.Range)
+	})
+
+	t.Run("references", func(t *testing.T) {
+		var locations []Location
+		params := position(0, 12)
+		params["context"] = map[string]any{"includeDeclaration": true}
+		c.roundTrip(c.request("textDocument/references", params)["result"], &locations)
+		require.Len(t, locations, 2)
+		require.Equal(t, Position{Line: 0, Character: 11}, locations[0].Range.Start)
+		require.Equal(t, Position{Line: 1, Character: 13}, locations[1].Range.Start)
+
+		params["context"] = map[string]any{"includeDeclaration": false}
+		c.roundTrip(c.request("textDocument/references", params)["result"], &locations)
+		require.Len(t, locations, 1)
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var hover Hover
+		c.roundTrip(c.request("textDocument/hover", position(10, 13))["result"], &hover)
+		require.Equal(t, "markdown", hover.Contents.Kind)
+		require.Equal(t, "```lox\nfun add(a, b)\n```\n\narity: 2", hover.Contents.Value)
+
+		require.Nil(t, c.request("textDocument/hover", position(3, 0))["result"])
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		var items []CompletionItem
+		c.roundTrip(c.request("textDocument/completion", position(11, 0))["result"], &items)
+
+		labels := make(map[string]CompletionItemKind)
+		for _, item := range items {
+			labels[item.Label] = item.Kind
+		}
+		require.Equal(t, CompletionItemKindKeyword, labels["while"])
+		require.Equal(t, CompletionItemKindFunction, labels["clock"])
+		require.Equal(t, CompletionItemKindFunction, labels["add"])
+		require.Equal(t, CompletionItemKindClass, labels["Point"])
+		require.Equal(t, CompletionItemKindVariable, labels["total"])
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": testURI, "version": 2},
+			"contentChanges": []map[string]any{{"text": "var x = ;\nprint 1"}},
+		})
+		c.roundTrip(c.receive()["params"], &published)
+		require.Len(t, published.Diagnostics, 2)
+		require.Equal(t, 1, published.Diagnostics[0].Severity)
+		require.Equal(t, Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 9}}, published.Diagnostics[0].Range)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		resp := c.request("textDocument/rename", map[string]any{})
+		require.EqualValues(t, codeMethodNotFound, resp["error"].(map[string]any)["code"])
+	})
+
+	require.Nil(t, c.request("shutdown", nil)["result"])
+	c.notify("exit", nil)
+	require.NoError(t, <-c.err)
+}
@@ -1,30 +1,127 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+
+	"github.com/unflag/go-lox/dap"
+	"github.com/unflag/go-lox/lox"
+	"github.com/unflag/go-lox/lsp"
 )
 
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Printf("Usage: %s [script]\n", os.Args[0])
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lsp":
+			if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "language server: %+v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "dap":
+			if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+				fmt.Fprintf(os.Stderr, "debug adapter: %+v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "fmt":
+			os.Exit(runFormat(os.Args[2:]))
+		case "debug":
+			os.Exit(runDebug(os.Args[2:]))
+		}
+	}
+
+	backend := flag.String("backend", string(lox.BackendTreeWalker), "execution backend: tree or vm")
+	errorFormat := flag.String("error-format", string(lox.ErrorFormatHuman), "error output format: human, plain or json")
+	searchPath := flag.String("path", "", "list of directories to search for imported modules")
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [-backend tree|vm] [-error-format human|plain|json] [-path dirs] [script]\n", os.Args[0])
+		fmt.Printf("       %s fmt [-w] [-d] [path ...]\n", os.Args[0])
+		fmt.Printf("       %s debug [-path dirs] script\n", os.Args[0])
+		fmt.Printf("       %s lsp\n", os.Args[0])
+		fmt.Printf("       %s dap\n", os.Args[0])
+	}
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		flag.Usage()
 		return
 	}
 
-	lox := NewLox()
+	switch lox.Backend(*backend) {
+	case lox.BackendTreeWalker, lox.BackendVM:
+	default:
+		fmt.Printf("unknown backend %q\n", *backend)
+		flag.Usage()
+		return
+	}
 
-	if len(os.Args) == 2 {
-		if err := lox.RunFile(os.Args[1]); err != nil {
-			fmt.Printf("could not execute file %s: %+v", os.Args[1], err)
-			return
+	switch lox.ErrorFormat(*errorFormat) {
+	case lox.ErrorFormatHuman, lox.ErrorFormatPlain, lox.ErrorFormatJSON:
+	default:
+		fmt.Printf("unknown error format %q\n", *errorFormat)
+		flag.Usage()
+		return
+	}
+
+	format := lox.ErrorFormat(*errorFormat)
+	vm := lox.New(
+		lox.WithBackend(lox.Backend(*backend)),
+		lox.WithSearchPath(filepath.SplitList(*searchPath)...),
+		lox.WithErrorFormat(format),
+	)
+	ctx := context.Background()
+
+	if flag.NArg() == 1 {
+		if err := vm.RunFile(ctx, flag.Arg(0)); err != nil {
+			exitOnExitError(err)
+			code, ok := exitCode(err)
+			if !ok {
+				fmt.Printf("could not execute file %s: %+v", flag.Arg(0), err)
+				return
+			}
+			vm.ReportError(err)
+			os.Exit(code)
 		}
 		return
 	}
 
-	if err := lox.RunPrompt(); err != nil {
+	if err := vm.RunPrompt(ctx); err != nil {
+		exitOnExitError(err)
 		fmt.Printf("could not execute input: %+v", err)
 		return
 	}
 
 	return
 }
+
+// exitOnExitError ends the process when the script called exit.
+func exitOnExitError(err error) {
+	var exitErr lox.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+}
+
+func exitCode(err error) (int, bool) {
+	var (
+		scanErr    lox.ScanError
+		parseErr   lox.ParseError
+		resolveErr lox.ResolveError
+		compileErr lox.CompileError
+		runtimeErr lox.RuntimeError
+	)
+
+	switch {
+	case errors.As(err, &runtimeErr):
+		return 70, true
+	case errors.As(err, &scanErr), errors.As(err, &parseErr), errors.As(err, &resolveErr), errors.As(err, &compileErr):
+		return 65, true
+	default:
+		return 0, false
+	}
+}